@@ -2,24 +2,37 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/yourname/moodle/internal/ai"
 	"github.com/yourname/moodle/internal/auth"
 	"github.com/yourname/moodle/internal/handlers"
+	"github.com/yourname/moodle/internal/health"
 	httpserver "github.com/yourname/moodle/internal/http"
+	"github.com/yourname/moodle/internal/logging"
+	"github.com/yourname/moodle/internal/metrics"
+	"github.com/yourname/moodle/internal/moderation"
+	"github.com/yourname/moodle/internal/moviecache"
+	"github.com/yourname/moodle/internal/openapi"
+	"github.com/yourname/moodle/internal/problem"
 	"github.com/yourname/moodle/internal/store"
 	"github.com/yourname/moodle/internal/tmdb"
+	"github.com/yourname/moodle/internal/tracing"
+	"github.com/yourname/moodle/migrations"
 )
 
 type Config struct {
@@ -34,75 +47,342 @@ type Config struct {
 	ClientURL            string `envconfig:"CLIENT_URL" default:"exp://192.168.0.5:8081/--/auth"`
 	TMDBAPIKey           string `envconfig:"TMDB_API_KEY" required:"true"`
 	TMDBBaseURL          string `envconfig:"TMDB_BASE_URL" default:"https://api.themoviedb.org/3"`
-	GeminiAPIKey         string `envconfig:"GEMINI_API_KEY" required:"true"`
-	GeminiModel          string `envconfig:"GEMINI_MODEL" default:"gemini-1.5-flash"`
+	// Outbound limits for the TMDb client, separate from RATE_LIMIT_TMDB_*
+	// which throttle our own callers.
+	TMDBRequestsPerSec  float64       `envconfig:"TMDB_REQUESTS_PER_SECOND" default:"40"`
+	TMDBBurst           int           `envconfig:"TMDB_BURST" default:"20"`
+	TMDBMaxAttempts     int           `envconfig:"TMDB_MAX_ATTEMPTS" default:"3"`
+	TMDBBreakerFailures int           `envconfig:"TMDB_BREAKER_FAILURES" default:"5"`
+	TMDBBreakerCooldown time.Duration `envconfig:"TMDB_BREAKER_COOLDOWN" default:"30s"`
+	GeminiAPIKey        string        `envconfig:"GEMINI_API_KEY" required:"true"`
+	GeminiModel         string        `envconfig:"GEMINI_MODEL" default:"gemini-1.5-flash"`
+	GeminiBaseURL       string        `envconfig:"GEMINI_BASE_URL" default:"https://generativelanguage.googleapis.com/v1"`
+
+	// Moderation of watchlist titles, descriptions and item notes
+	ModerationPolicy       string   `envconfig:"MODERATION_POLICY" default:"reject"`
+	ModerationWords        []string `envconfig:"MODERATION_WORDS"`
+	ModerationWordlistFile string   `envconfig:"MODERATION_WORDLIST_FILE"`
+	ModerationClassifier   bool     `envconfig:"MODERATION_CLASSIFIER" default:"false"`
+
+	// Rate limiting: backend is memory (per instance) or postgres (shared)
+	RateLimitBackend    string `envconfig:"RATE_LIMIT_BACKEND" default:"memory"`
+	RateLimitAIPerMin   int    `envconfig:"RATE_LIMIT_AI_PER_MIN" default:"10"`
+	RateLimitAIBurst    int    `envconfig:"RATE_LIMIT_AI_BURST" default:"5"`
+	RateLimitTMDBPerMin int    `envconfig:"RATE_LIMIT_TMDB_PER_MIN" default:"60"`
+	RateLimitTMDBBurst  int    `envconfig:"RATE_LIMIT_TMDB_BURST" default:"20"`
+	RateLimitAPIPerMin  int    `envconfig:"RATE_LIMIT_API_PER_MIN" default:"300"`
+	RateLimitAPIBurst   int    `envconfig:"RATE_LIMIT_API_BURST" default:"60"`
+
+	// Idempotency-Key storage: memory (per instance) or postgres (shared)
+	IdempotencyBackend string        `envconfig:"IDEMPOTENCY_BACKEND" default:"memory"`
+	IdempotencyTTL     time.Duration `envconfig:"IDEMPOTENCY_TTL" default:"24h"`
+
+	// Reject requests that do not match the OpenAPI document
+	OpenAPIValidate bool `envconfig:"OPENAPI_VALIDATE" default:"false"`
+
+	// HTTP server timeouts and shutdown
+	HTTPReadHeaderTimeout time.Duration `envconfig:"HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+	HTTPReadTimeout       time.Duration `envconfig:"HTTP_READ_TIMEOUT" default:"15s"`
+	HTTPWriteTimeout      time.Duration `envconfig:"HTTP_WRITE_TIMEOUT" default:"75s"`
+	HTTPIdleTimeout       time.Duration `envconfig:"HTTP_IDLE_TIMEOUT" default:"120s"`
+	ShutdownDrainDelay    time.Duration `envconfig:"SHUTDOWN_DRAIN_DELAY" default:"5s"`
+	ShutdownTimeout       time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"25s"`
+
+	// Readiness checks
+	MigrationsTable        string        `envconfig:"MIGRATIONS_TABLE" default:"goose_db_version"`
+	HealthDBTimeout        time.Duration `envconfig:"HEALTH_DB_TIMEOUT" default:"2s"`
+	HealthCheckUpstreams   bool          `envconfig:"HEALTH_CHECK_UPSTREAMS" default:"false"`
+	HealthUpstreamCacheTTL time.Duration `envconfig:"HEALTH_UPSTREAM_CACHE_TTL" default:"5m"`
+
+	// Bearer token required to scrape /metrics; empty leaves it open
+	MetricsToken string `envconfig:"METRICS_TOKEN"`
+
+	// Apply pending migrations before serving; replicas serialize on an advisory lock
+	MigrateOnBoot bool `envconfig:"MIGRATE_ON_BOOT" default:"false"`
+
+	// Movie details cached in the movies table: how long they are served
+	// before TMDb is asked again, and how often and how many stale ones
+	// are refetched in the background
+	MovieCacheTTL        time.Duration `envconfig:"MOVIE_CACHE_TTL" default:"24h"`
+	MovieRefreshInterval time.Duration `envconfig:"MOVIE_REFRESH_INTERVAL" default:"10m"`
+	MovieRefreshBatch    int           `envconfig:"MOVIE_REFRESH_BATCH" default:"100"`
+
+	// OpenTelemetry tracing, off unless TRACING_ENABLED is set
+	TracingEnabled     bool    `envconfig:"TRACING_ENABLED" default:"false"`
+	TracingServiceName string  `envconfig:"OTEL_SERVICE_NAME" default:"moodle-api"`
+	TracingEndpoint    string  `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT" default:"localhost:4318"`
+	TracingInsecure    bool    `envconfig:"OTEL_EXPORTER_OTLP_INSECURE" default:"false"`
+	TracingSampleRatio float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`
+
+	// Logging
+	LogLevel  string `envconfig:"LOG_LEVEL" default:"info"`
+	LogFormat string `envconfig:"LOG_FORMAT" default:"json"`
 }
 
+// version is stamped at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
 func mustLoadEnv() Config {
-	_ = godotenv.Load()
 	var c Config
-	if err := envconfig.Process("", &c); err != nil {
+	mustProcessEnv(&c)
+	mustSetupLogging(c.LogLevel, c.LogFormat)
+	return c
+}
+
+// mustProcessEnv fills spec from the environment and .env.
+func mustProcessEnv(spec any) {
+	_ = godotenv.Load()
+	if err := envconfig.Process("", spec); err != nil {
 		log.Fatalf("env error: %v", err)
 	}
-	return c
+}
+
+func mustSetupLogging(level, format string) {
+	if err := logging.Setup(os.Stderr, logging.Config{Level: level, Format: format}); err != nil {
+		log.Fatalf("logging: %v", err)
+	}
+}
+
+// fatal logs msg at error level and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
 
 func mustDB(dsn string) *gorm.DB {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: gormlogger.New(logging.Printer{Level: slog.LevelWarn}, gormlogger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  gormlogger.Warn,
+			IgnoreRecordNotFoundError: true,
+		}),
+	})
 	if err != nil {
-		log.Fatalf("db connect error: %v", err)
+		fatal("db connect error", "err", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		fatal("db tracing error", "err", err)
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	sqlDB, _ := db.DB()
 	if err := sqlDB.PingContext(ctx); err != nil {
-		log.Fatalf("db ping error: %v", err)
+		fatal("db ping error", "err", err)
 	}
 	return db
 }
 
+func mustModeration(c Config, aiClient *ai.GeminiClient) *moderation.Pipeline {
+	policy, err := moderation.ParsePolicy(c.ModerationPolicy)
+	if err != nil {
+		fatal("moderation", "err", err)
+	}
+	words := c.ModerationWords
+	if c.ModerationWordlistFile != "" {
+		fromFile, err := moderation.ReadWords(c.ModerationWordlistFile)
+		if err != nil {
+			fatal("moderation wordlist", "err", err)
+		}
+		words = append(words, fromFile...)
+	}
+	p := &moderation.Pipeline{Policy: policy, Wordlist: moderation.NewWordlist(words)}
+	if c.ModerationClassifier {
+		p.Classifier = aiClient
+	}
+	return p
+}
+
+func mustRateLimiter(ctx context.Context, c Config, db *gorm.DB, bg *workers) httpserver.RateLimitBackend {
+	switch c.RateLimitBackend {
+	case "memory":
+		return httpserver.NewMemoryRateLimiter()
+	case "postgres":
+		rl := httpserver.NewPostgresRateLimiter(db)
+		bg.every(ctx, "ratelimit prune", 10*time.Minute, rl.Prune)
+		return rl
+	default:
+		fatal("unknown RATE_LIMIT_BACKEND", "value", c.RateLimitBackend)
+		return nil
+	}
+}
+
+func mustIdempotency(ctx context.Context, c Config, db *gorm.DB, bg *workers) httpserver.IdempotencyBackend {
+	switch c.IdempotencyBackend {
+	case "memory":
+		return httpserver.NewMemoryIdempotency()
+	case "postgres":
+		ib := httpserver.NewPostgresIdempotency(db)
+		bg.every(ctx, "idempotency prune", time.Hour, ib.Prune)
+		return ib
+	default:
+		fatal("unknown IDEMPOTENCY_BACKEND", "value", c.IdempotencyBackend)
+		return nil
+	}
+}
+
+func healthChecks(c Config, db *gorm.DB, tmdbClient *tmdb.Client, aiClient *ai.GeminiClient) *health.Registry {
+	reg := health.NewRegistry()
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("db handle", "err", err)
+	}
+	reg.Register(health.Check{Name: "postgres", Fn: health.DB(sqlDB), Timeout: c.HealthDBTimeout})
+	reg.Register(health.Check{Name: "migrations", Fn: health.Migrations(sqlDB, migrations.FS, c.MigrationsTable), Timeout: c.HealthDBTimeout, CacheTTL: 30 * time.Second})
+	if c.HealthCheckUpstreams {
+		// Upstream probes spend quota, so cache them and never fail readiness on them.
+		reg.Register(health.Check{Name: "tmdb", Fn: tmdbClient.Ping, Timeout: 5 * time.Second, CacheTTL: c.HealthUpstreamCacheTTL, Optional: true})
+		reg.Register(health.Check{Name: "gemini", Fn: aiClient.Ping, Timeout: 5 * time.Second, CacheTTL: c.HealthUpstreamCacheTTL, Optional: true})
+	}
+	return reg
+}
+
+// metricsHandler serves /metrics, requiring "Bearer <token>" when token is set.
+func metricsHandler(token string) http.Handler {
+	h := metrics.Handler()
+	if token == "" {
+		return h
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			problem.Unauthorized(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+const usage = `usage: moodle <command>
+
+commands:
+  serve                     run the API server (default)
+  migrate up|down|status    apply, roll back one, or list migrations
+  version                   print the build version
+`
+
 func main() {
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+	switch cmd {
+	case "serve":
+		serve()
+	case "migrate":
+		runMigrate(args)
+	case "version":
+		printVersion()
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+}
+
+func serve() {
 	cfg := mustLoadEnv()
+	// SIGTERM (deploys) and SIGINT (ctrl-c) start a graceful shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	var bg workers
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Enabled:     cfg.TracingEnabled,
+		ServiceName: cfg.TracingServiceName,
+		Version:     version,
+		Endpoint:    cfg.TracingEndpoint,
+		Insecure:    cfg.TracingInsecure,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("tracing setup error", "err", err)
+	}
+
 	db := mustDB(cfg.DatabaseURL)
+	if cfg.MigrateOnBoot {
+		mustMigrateUp(ctx, db, cfg.MigrationsTable)
+	}
 	st := store.New(db)
 	tmdbClient := tmdb.New(cfg.TMDBAPIKey, cfg.TMDBBaseURL)
-	aiClient := ai.NewGemini(cfg.GeminiAPIKey, cfg.GeminiModel)
+	tmdbClient.Retry.Attempts = cfg.TMDBMaxAttempts
+	tmdbClient.SetRateLimit(cfg.TMDBRequestsPerSec, cfg.TMDBBurst)
+	tmdbClient.SetBreaker(tmdb.BreakerPolicy{Failures: cfg.TMDBBreakerFailures, Cooldown: cfg.TMDBBreakerCooldown})
+	aiClient := ai.NewGemini(cfg.GeminiAPIKey, cfg.GeminiModel, cfg.GeminiBaseURL)
+	metrics.InstrumentClient(tmdbClient.HTTP, "tmdb")
+	metrics.InstrumentClient(aiClient.HTTP, "gemini")
+	tracing.InstrumentClient(tmdbClient.HTTP, "tmdb")
+	tracing.InstrumentClient(aiClient.HTTP, "gemini")
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDB(sqlDB, "postgres")
+	}
+
+	movies := moviecache.New(tmdbClient, st)
+	movies.TTL, movies.Batch = cfg.MovieCacheTTL, cfg.MovieRefreshBatch
+	metrics.RegisterCache("movies", movies)
 
 	// Handlers
-	wlHandler := handlers.NewWatchlistHandler(st, tmdbClient)
+	wlHandler := handlers.NewWatchlistHandler(st, movies, mustModeration(cfg, aiClient))
+	metrics.RegisterCache("feed", wlHandler.FeedCache)
+	metrics.RegisterCache("watch_providers", wlHandler.Providers)
 	aiHandler := handlers.NewAIHandler(aiClient)
 	userHandler := handlers.NewUserHandler(st)
 	authHandler := handlers.NewAuthHandler(st, cfg.SupabaseURL, cfg.SupabaseAnonKey, cfg.ClientURL)
 
 	// Auth middleware
-	verifier := &auth.SupabaseVerifier{PublicKeyPEMOrJWKS: cfg.SupabaseJWTPublicKey, JWKSURL: cfg.SupabaseJWKSURL, Audience: cfg.SupabaseJWTAudience, Issuer: cfg.SupabaseJWTIssuer}
-
-	mounter := func(r chi.Router) {
-		// Public routes
-		r.Group(func(r chi.Router) {
-			r.Get("/search/movies", wlHandler.SearchMovies)
-			r.Get("/movies/{id}", wlHandler.Movie)
-			r.Get("/feed", wlHandler.Feed)
-			r.Post("/ai/ask", aiHandler.Ask)
-			// Auth routes (public)
-			r.Route("/auth", authHandler.Routes)
-		})
-		// Authed routes
-		r.Group(func(r chi.Router) {
-			r.Use(verifier.Middleware)
-			r.Get("/me", userHandler.Me)
-			r.Route("/watchlists", wlHandler.Routes)
-			// trending can be public but keep here for now or move above
-			r.Get("/trending", wlHandler.Trending)
-		})
-	}
-
-	srv := httpserver.NewServer(mounter)
-
-	addr := ":" + cfg.Port
-	log.Printf("listening on %s", addr)
-	if err := http.ListenAndServe(addr, srv.Router); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	verifier := &auth.SupabaseVerifier{PublicKeyPEMOrJWKS: cfg.SupabaseJWTPublicKey, JWKSURL: cfg.SupabaseJWKSURL, Audience: cfg.SupabaseJWTAudience, Issuer: cfg.SupabaseJWTIssuer, APITokens: st.UserForAPIToken}
+
+	bg.every(ctx, "movie refresh", cfg.MovieRefreshInterval, movies.Refresh)
+	bg.every(ctx, "watch providers prune", time.Hour, wlHandler.Providers.Prune)
+
+	// Rate limits: separate buckets for the paid Gemini API, the TMDb quota
+	// and everything else.
+	limiter := mustRateLimiter(ctx, cfg, db, &bg)
+	aiLimit := httpserver.RateLimit(limiter, httpserver.RatePolicy{Name: "ai", PerMinute: cfg.RateLimitAIPerMin, Burst: cfg.RateLimitAIBurst})
+	tmdbLimit := httpserver.RateLimit(limiter, httpserver.RatePolicy{Name: "tmdb", PerMinute: cfg.RateLimitTMDBPerMin, Burst: cfg.RateLimitTMDBBurst})
+	apiLimit := httpserver.RateLimit(limiter, httpserver.RatePolicy{Name: "api", PerMinute: cfg.RateLimitAPIPerMin, Burst: cfg.RateLimitAPIBurst})
+	idempotent := httpserver.Idempotency(mustIdempotency(ctx, cfg, db, &bg), cfg.IdempotencyTTL)
+
+	spec := handlers.APISpec()
+
+	rt := &routes{
+		spec:       spec,
+		validate:   cfg.OpenAPIValidate,
+		watchlists: wlHandler,
+		ai:         aiHandler,
+		users:      userHandler,
+		auth:       authHandler,
+		verifier:   verifier.Middleware,
+		idempotent: idempotent,
+		aiLimit:    aiLimit,
+		tmdbLimit:  tmdbLimit,
+		apiLimit:   apiLimit,
+	}
+	srv := httpserver.NewServer(rt.mount)
+	srv.UseChecks(healthChecks(cfg, db, tmdbClient, aiClient))
+	srv.Router.Handle("/metrics", metricsHandler(cfg.MetricsToken))
+	if err := openapi.Verify(spec, srv.Router, "/v1"); err != nil {
+		fatal("openapi document does not match routes", "err", err)
+	}
+
+	hs := srv.HTTPServer(":"+cfg.Port, httpserver.Timeouts{
+		ReadHeader: cfg.HTTPReadHeaderTimeout,
+		Read:       cfg.HTTPReadTimeout,
+		Write:      cfg.HTTPWriteTimeout,
+		Idle:       cfg.HTTPIdleTimeout,
+	})
+	err = srv.Serve(ctx, hs, httpserver.Lifecycle{DrainDelay: cfg.ShutdownDrainDelay, ShutdownTimeout: cfg.ShutdownTimeout})
+	// Stop background workers whether we got a signal or the listener failed.
+	stop()
+	bg.wait(5 * time.Second)
+	closeDB(db)
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if tErr := shutdownTracing(flushCtx); tErr != nil {
+		slog.Warn("tracing shutdown failed", "err", tErr)
+	}
+	cancel()
+	if err != nil {
+		fatal("server error", "err", err)
 	}
+	slog.Info("shutdown complete")
 }
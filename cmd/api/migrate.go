@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"gorm.io/gorm"
+
+	"github.com/yourname/moodle/internal/migrate"
+)
+
+// migrateConfig is the subset of Config the migrate command needs, so it
+// runs without API keys in the environment.
+type migrateConfig struct {
+	DatabaseURL     string `envconfig:"DATABASE_URL" required:"true"`
+	MigrationsTable string `envconfig:"MIGRATIONS_TABLE" default:"goose_db_version"`
+	LogLevel        string `envconfig:"LOG_LEVEL" default:"info"`
+	LogFormat       string `envconfig:"LOG_FORMAT" default:"json"`
+}
+
+func runMigrate(args []string) {
+	if len(args) != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var c migrateConfig
+	mustProcessEnv(&c)
+	mustSetupLogging(c.LogLevel, c.LogFormat)
+
+	ctx := context.Background()
+	db := mustDB(c.DatabaseURL)
+	defer closeDB(db)
+	p := mustProvider(db, c.MigrationsTable)
+
+	switch args[0] {
+	case "up":
+		results, err := p.Up(ctx)
+		printResults(results)
+		if err != nil {
+			fatal("migrate up failed", "err", err)
+		}
+	case "down":
+		res, err := p.Down(ctx)
+		if res != nil {
+			printResults([]*goose.MigrationResult{res})
+		}
+		if err != nil {
+			fatal("migrate down failed", "err", err)
+		}
+	case "status":
+		statuses, err := p.Status(ctx)
+		if err != nil {
+			fatal("migrate status failed", "err", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "APPLIED AT\tMIGRATION")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.State == goose.StateApplied {
+				applied = s.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", applied, s.Source.Path)
+		}
+		tw.Flush()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n\n%s", args[0], usage)
+		os.Exit(2)
+	}
+}
+
+// mustMigrateUp applies pending migrations at boot. The provider's advisory
+// lock makes concurrent replicas wait for whichever got there first.
+func mustMigrateUp(ctx context.Context, db *gorm.DB, table string) {
+	results, err := mustProvider(db, table).Up(ctx)
+	if err != nil {
+		fatal("migrate on boot failed", "err", err)
+	}
+	slog.Info("migrations applied", "count", len(results))
+}
+
+func mustProvider(db *gorm.DB, table string) *goose.Provider {
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("db handle", "err", err)
+	}
+	p, err := migrate.NewProvider(sqlDB, table)
+	if err != nil {
+		fatal("migrations", "err", err)
+	}
+	return p
+}
+
+func printResults(results []*goose.MigrationResult) {
+	if len(results) == 0 {
+		fmt.Println("no migrations to run")
+	}
+	for _, r := range results {
+		fmt.Println(r)
+	}
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+}
+
+func printVersion() {
+	rev := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				rev = s.Value
+			}
+		}
+	}
+	fmt.Printf("moodle %s (commit %s, %s)\n", version, rev, runtime.Version())
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/yourname/moodle/internal/handlers"
+	"github.com/yourname/moodle/internal/openapi"
+)
+
+// middleware wraps a handler, e.g. a rate limit.
+type middleware = func(http.Handler) http.Handler
+
+// routes is everything the /v1 routes are built from.
+type routes struct {
+	spec *openapi.Document
+	// validate checks requests against spec before the handlers see them.
+	validate bool
+
+	watchlists *handlers.WatchlistHandler
+	ai         *handlers.AIHandler
+	users      *handlers.UserHandler
+	auth       *handlers.AuthHandler
+
+	verifier, idempotent         middleware
+	aiLimit, tmdbLimit, apiLimit middleware
+}
+
+// mount registers the /v1 routes on r.
+func (rt *routes) mount(r chi.Router) {
+	if rt.validate {
+		r.Use(openapi.Validator(rt.spec, "/v1"))
+	}
+	r.Get("/openapi.json", handlers.OpenAPIHandler(rt.spec))
+	// Public routes
+	r.Group(func(r chi.Router) {
+		r.With(rt.tmdbLimit).Get("/search/movies", rt.watchlists.SearchMovies)
+		r.With(rt.tmdbLimit).Get("/movies/{id}", rt.watchlists.Movie)
+		r.With(rt.tmdbLimit).Get("/search/multi", rt.watchlists.SearchMulti)
+		r.With(rt.tmdbLimit).Get("/tv/{id}", rt.watchlists.TV)
+		r.With(rt.tmdbLimit).Get("/tv/{id}/season/{season}", rt.watchlists.TVSeason)
+		r.With(rt.tmdbLimit).Get("/feed", rt.watchlists.Feed)
+		r.With(rt.aiLimit).Post("/ai/ask", rt.ai.Ask)
+		// Auth routes (public)
+		r.With(rt.apiLimit).Route("/auth", rt.auth.Routes)
+	})
+	// Authed routes
+	r.Group(func(r chi.Router) {
+		r.Use(rt.verifier)
+		r.Use(rt.apiLimit)
+		r.Use(rt.idempotent)
+		r.Get("/me", rt.users.Me)
+		r.Route("/users", rt.users.Routes)
+		r.Route("/watchlists", rt.watchlists.Routes)
+		// trending can be public but keep here for now or move above
+		r.Get("/trending", rt.watchlists.Trending)
+	})
+}
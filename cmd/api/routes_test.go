@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yourname/moodle/internal/handlers"
+	httpserver "github.com/yourname/moodle/internal/http"
+	"github.com/yourname/moodle/internal/openapi"
+	"github.com/yourname/moodle/internal/store/memstore"
+)
+
+// TestRoutesMatchOpenAPI runs the boot-time openapi.Verify check over the
+// router main builds, with every optional route mounted.
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	pass := func(next http.Handler) http.Handler { return next }
+	st := memstore.New()
+	rt := &routes{
+		spec:       handlers.APISpec(),
+		validate:   true,
+		watchlists: handlers.NewWatchlistHandler(st, nil, nil),
+		ai:         handlers.NewAIHandler(nil),
+		users:      handlers.NewUserHandler(st),
+		auth:       handlers.NewAuthHandler(nil, "", "", ""),
+		verifier:   pass,
+		idempotent: pass,
+		aiLimit:    pass,
+		tmdbLimit:  pass,
+		apiLimit:   pass,
+	}
+	srv := httpserver.NewServer(rt.mount)
+	if err := openapi.Verify(rt.spec, srv.Router, "/v1"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// workers runs periodic background jobs until their context is cancelled.
+type workers struct{ wg sync.WaitGroup }
+
+// every runs fn each interval until ctx is done. A run in progress gets to
+// finish; shutdown waits for it in wait.
+func (w *workers) every(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := fn(ctx); err != nil && ctx.Err() == nil {
+					slog.Error("background job failed", slog.String("job", name), slog.Any("err", err))
+				}
+			}
+		}
+	}()
+}
+
+// wait blocks until every job has returned or timeout passes.
+func (w *workers) wait(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		slog.Warn("shutdown: background workers still running", slog.Duration("timeout", timeout))
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/moviecache"
+	"github.com/yourname/moodle/internal/store"
+)
+
+func listWatchlists(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("watchlists list", flag.ContinueOnError)
+	var f store.WatchlistFilter
+	fs.StringVar(&f.OwnerID, "owner", "", "only lists owned by this user ID")
+	fs.StringVar(&f.Query, "q", "", "only lists whose title contains this text")
+	fs.IntVar(&f.Limit, "limit", 50, "maximum number of lists")
+	if _, err := positional(fs, args, 0); err != nil {
+		return err
+	}
+	lists, err := e.store.ListWatchlists(ctx, f)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(lists))
+	for i, wl := range lists {
+		rows[i] = summaryRow(wl)
+	}
+	return e.out.print(lists, summaryHeader, rows)
+}
+
+var summaryHeader = []string{"ID", "OWNER", "TITLE", "PUBLIC", "MODERATION", "ITEMS", "LIKES", "SHARES", "CREATED"}
+
+func summaryRow(wl store.WatchlistSummary) []string {
+	return []string{
+		wl.ID, wl.OwnerID, wl.Title, strconv.FormatBool(wl.IsPublic), wl.ModerationStatus,
+		strconv.FormatInt(wl.ItemCount, 10), strconv.FormatInt(wl.LikeCount, 10), strconv.FormatInt(wl.ShareCount, 10),
+		fmtTime(&wl.CreatedAt),
+	}
+}
+
+func inspectWatchlist(ctx context.Context, e *env, args []string) error {
+	pos, err := positional(flag.NewFlagSet("watchlists inspect", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	wl, err := e.store.InspectWatchlist(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	if e.out.json {
+		return e.out.print(wl, nil, nil)
+	}
+	if err := e.out.print(nil, summaryHeader, [][]string{summaryRow(*wl)}); err != nil {
+		return err
+	}
+	fmt.Fprintln(e.out.w)
+	rows := make([][]string, len(wl.Items))
+	for i, it := range wl.Items {
+		rows[i] = []string{strconv.Itoa(it.Position), it.ID, it.MediaType, strconv.FormatInt(it.TMDBID, 10), it.Title, it.ReleaseDate, it.ModerationStatus}
+	}
+	return e.out.print(nil, []string{"POS", "ITEM", "MEDIA", "TMDB", "TITLE", "RELEASED", "MODERATION"}, rows)
+}
+
+func transferWatchlist(ctx context.Context, e *env, args []string) error {
+	pos, err := positional(flag.NewFlagSet("watchlists transfer", flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+	if err := e.store.TransferWatchlist(ctx, pos[0], pos[1]); err != nil {
+		return err
+	}
+	return e.out.message(fmt.Sprintf("watchlist %s now belongs to %s", pos[0], pos[1]), map[string]any{"watchlist_id": pos[0], "owner_id": pos[1]})
+}
+
+func mergeUsers(ctx context.Context, e *env, args []string) error {
+	pos, err := positional(flag.NewFlagSet("users merge", flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+	if err := e.store.MergeUsers(ctx, pos[0], pos[1]); err != nil {
+		return err
+	}
+	return e.out.message(fmt.Sprintf("merged %s into %s", pos[1], pos[0]), map[string]any{"kept": pos[0], "merged": pos[1]})
+}
+
+type resyncResult struct {
+	TMDBID  int64  `json:"tmdb_id"`
+	Title   string `json:"title,omitempty"`
+	Updated int64  `json:"updated"`
+	Error   string `json:"error,omitempty"`
+}
+
+func resyncItems(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("items resync", flag.ContinueOnError)
+	watchlist := fs.String("watchlist", "", "only items on this list")
+	if _, err := positional(fs, args, 0); err != nil {
+		return err
+	}
+	client, err := e.tmdb()
+	if err != nil {
+		return err
+	}
+	// Watchlists read the movies table first, so refresh it along with
+	// the copies on the items.
+	movies := moviecache.New(client, e.store)
+	ids, err := e.store.ItemTMDBIDs(ctx, *watchlist)
+	if err != nil {
+		return err
+	}
+	// One movie failing (say, removed from TMDb) should not stop the rest.
+	results := make([]resyncResult, 0, len(ids))
+	rows := make([][]string, 0, len(ids))
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		res := resyncResult{TMDBID: id}
+		if mv, err := movies.Fetch(ctx, id); err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Title = mv.Title
+			res.Updated, err = e.store.UpdateItemMetadata(ctx, id, mv.Title, mv.PosterPath, mv.ReleaseDate)
+			if err != nil {
+				return err
+			}
+		}
+		results = append(results, res)
+		rows = append(rows, []string{strconv.FormatInt(id, 10), res.Title, strconv.FormatInt(res.Updated, 10), res.Error})
+	}
+	return e.out.print(results, []string{"TMDB", "TITLE", "UPDATED", "ERROR"}, rows)
+}
+
+// showTrending prints what /trending currently ranks, as an anonymous
+// viewer sees it.
+func showTrending(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("trending show", flag.ContinueOnError)
+	window := fs.String("window", "week", "week, month or all")
+	limit := fs.Int("limit", 20, "maximum number of lists")
+	if _, err := positional(fs, args, 0); err != nil {
+		return err
+	}
+	switch *window {
+	case "week", "month":
+	case "all":
+		*window = ""
+	default:
+		return fmt.Errorf("unknown window %q: want week, month or all", *window)
+	}
+	lists, err := e.store.TopWatchlists(ctx, "", *window, *limit)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(lists))
+	for i, wl := range lists {
+		rows[i] = []string{strconv.Itoa(i + 1), wl.ID, wl.OwnerID, wl.Title, fmtTime(&wl.UpdatedAt)}
+	}
+	return e.out.print(lists, []string{"RANK", "ID", "OWNER", "TITLE", "UPDATED"}, rows)
+}
+
+// listReview prints the lists and items held for review, oldest first.
+func listReview(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("moderation list", flag.ContinueOnError)
+	limit := fs.Int("limit", 50, "maximum number of lists, and of items")
+	if _, err := positional(fs, args, 0); err != nil {
+		return err
+	}
+	q, err := e.store.PendingReview(ctx, *limit)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(q.Watchlists)+len(q.Items))
+	for _, wl := range q.Watchlists {
+		rows = append(rows, []string{"watchlist", wl.ID, wl.ID, wl.OwnerID, wl.Title + " / " + wl.Description, fmtTime(&wl.CreatedAt)})
+	}
+	for _, it := range q.Items {
+		rows = append(rows, []string{"item", it.ID, it.WatchlistID, "-", it.Notes, fmtTime(&it.CreatedAt)})
+	}
+	return e.out.print(q, []string{"KIND", "ID", "WATCHLIST", "OWNER", "TEXT", "CREATED"}, rows)
+}
+
+func approveReview(ctx context.Context, e *env, args []string) error {
+	return review(ctx, e, "moderation approve", models.ModerationOK, args)
+}
+
+func rejectReview(ctx context.Context, e *env, args []string) error {
+	return review(ctx, e, "moderation reject", models.ModerationRejected, args)
+}
+
+// review records status on the watchlist or item named by args.
+func review(ctx context.Context, e *env, name, status string, args []string) error {
+	pos, err := positional(flag.NewFlagSet(name, flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+	switch pos[0] {
+	case "watchlist":
+		err = e.store.ReviewWatchlist(ctx, pos[1], status)
+	case "item":
+		err = e.store.ReviewItem(ctx, pos[1], status)
+	default:
+		return fmt.Errorf("%s: want watchlist or item, got %q", name, pos[0])
+	}
+	if err != nil {
+		return err
+	}
+	return e.out.message(fmt.Sprintf("%s %s is now %s", pos[0], pos[1], status), map[string]any{"kind": pos[0], "id": pos[1], "moderation_status": status})
+}
+
+var tokenHeader = []string{"ID", "USER", "NAME", "PREFIX", "CREATED", "EXPIRES", "LAST USED", "REVOKED"}
+
+func tokenRow(t models.APIToken) []string {
+	return []string{t.ID, t.UserID, t.Name, t.Prefix, fmtTime(&t.CreatedAt), fmtTime(t.ExpiresAt), fmtTime(t.LastUsedAt), fmtTime(t.RevokedAt)}
+}
+
+func createToken(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("tokens create", flag.ContinueOnError)
+	user := fs.String("user", "", "user ID the token acts as")
+	name := fs.String("name", "", "what the token is for")
+	ttl := fs.Duration("ttl", 0, "lifetime, e.g. 720h (0 never expires)")
+	if _, err := positional(fs, args, 0); err != nil {
+		return err
+	}
+	if *user == "" || *name == "" {
+		return fmt.Errorf("tokens create: -user and -name are required")
+	}
+	t := &models.APIToken{UserID: *user, Name: *name}
+	if *ttl > 0 {
+		exp := time.Now().Add(*ttl)
+		t.ExpiresAt = &exp
+	}
+	raw, err := e.store.CreateAPIToken(ctx, t)
+	if err != nil {
+		return err
+	}
+	if e.out.json {
+		return e.out.print(struct {
+			models.APIToken
+			Token string `json:"token"`
+		}{*t, raw}, nil, nil)
+	}
+	if err := e.out.print(nil, tokenHeader, [][]string{tokenRow(*t)}); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.out.w, "\ntoken (shown once): %s\n", raw)
+	return err
+}
+
+func listTokens(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("tokens list", flag.ContinueOnError)
+	user := fs.String("user", "", "user ID")
+	if _, err := positional(fs, args, 0); err != nil {
+		return err
+	}
+	if *user == "" {
+		return fmt.Errorf("tokens list: -user is required")
+	}
+	tokens, err := e.store.ListAPITokens(ctx, *user)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(tokens))
+	for i, t := range tokens {
+		rows[i] = tokenRow(t)
+	}
+	return e.out.print(tokens, tokenHeader, rows)
+}
+
+func revokeToken(ctx context.Context, e *env, args []string) error {
+	pos, err := positional(flag.NewFlagSet("tokens revoke", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	if err := e.store.RevokeAPIToken(ctx, pos[0]); err != nil {
+		return err
+	}
+	return e.out.message("revoked token "+pos[0], map[string]any{"id": pos[0]})
+}
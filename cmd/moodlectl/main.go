@@ -0,0 +1,130 @@
+// Command moodlectl runs operational tasks against the moodle database:
+// watchlist listing and ownership transfers, user merges, TMDb metadata
+// re-syncs, a trending view, the moderation queue, API token management
+// and seeding a development database.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/joho/godotenv"
+	"github.com/kelseyhightower/envconfig"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/yourname/moodle/internal/store"
+	"github.com/yourname/moodle/internal/tmdb"
+)
+
+type Config struct {
+	DatabaseURL string `envconfig:"DATABASE_URL" required:"true"`
+	TMDBAPIKey  string `envconfig:"TMDB_API_KEY"`
+	TMDBBaseURL string `envconfig:"TMDB_BASE_URL" default:"https://api.themoviedb.org/3"`
+}
+
+const usage = `usage: moodlectl [-o table|json] <command> [args]
+
+commands:
+  watchlists list [-owner USER] [-q TITLE] [-limit N]
+  watchlists inspect WATCHLIST
+  watchlists transfer WATCHLIST NEW_OWNER
+  users merge KEEP_USER DUPLICATE_USER
+  items resync [-watchlist WATCHLIST]
+  trending show [-window week|month|all] [-limit N]
+  moderation list [-limit N]
+  moderation approve watchlist|item ID
+  moderation reject watchlist|item ID
+  tokens create -user USER -name NAME [-ttl DURATION]
+  tokens list -user USER
+  tokens revoke TOKEN_ID
+  seed run [-seed N] [-users N] [-watchlists N] [-items N] [-likes N] [-shares N] [-now DATE] [-clean]
+  seed clean
+`
+
+// env is what a command gets to work with.
+type env struct {
+	cfg   Config
+	store *store.Store
+	out   output
+}
+
+// tmdb returns a client for commands that call TMDb.
+func (e *env) tmdb() (*tmdb.Client, error) {
+	if e.cfg.TMDBAPIKey == "" {
+		return nil, errors.New("TMDB_API_KEY is not set")
+	}
+	return tmdb.New(e.cfg.TMDBAPIKey, e.cfg.TMDBBaseURL), nil
+}
+
+type command func(ctx context.Context, e *env, args []string) error
+
+var commands = map[string]map[string]command{
+	"watchlists": {"list": listWatchlists, "inspect": inspectWatchlist, "transfer": transferWatchlist},
+	"users":      {"merge": mergeUsers},
+	"items":      {"resync": resyncItems},
+	"trending":   {"show": showTrending},
+	"moderation": {"list": listReview, "approve": approveReview, "reject": rejectReview},
+	"tokens":     {"create": createToken, "list": listTokens, "revoke": revokeToken},
+	"seed":       {"run": runSeed, "clean": cleanSeed},
+}
+
+func main() {
+	format := flag.String("o", "table", "output format: table or json")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	args := flag.Args()
+	if len(args) < 2 || (*format != "table" && *format != "json") {
+		flag.Usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[args[0]][args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0]+" "+args[1])
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	_ = godotenv.Load()
+	var cfg Config
+	if err := envconfig.Process("", &cfg); err != nil {
+		fail(err)
+	}
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Warn)})
+	if err != nil {
+		fail(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	e := &env{cfg: cfg, store: store.New(db), out: output{json: *format == "json", w: os.Stdout}}
+	err = cmd(ctx, e, args[2:])
+	if sqlDB, dbErr := db.DB(); dbErr == nil {
+		_ = sqlDB.Close()
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "moodlectl: %v\n", err)
+	os.Exit(1)
+}
+
+// positional parses flags from args into fs and checks that exactly n
+// positional arguments remain.
+func positional(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != n {
+		return nil, fmt.Errorf("%s: expected %d argument(s), got %d", fs.Name(), n, fs.NArg())
+	}
+	return fs.Args(), nil
+}
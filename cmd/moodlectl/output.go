@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// output writes results either as an aligned table or as indented JSON.
+type output struct {
+	json bool
+	w    io.Writer
+}
+
+// print writes v as JSON, or header and rows as a table.
+func (o output) print(v any, header []string, rows [][]string) error {
+	if o.json {
+		enc := json.NewEncoder(o.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	return tw.Flush()
+}
+
+// message writes a one-line result, wrapped in an object for JSON.
+func (o output) message(msg string, fields map[string]any) error {
+	if o.json {
+		if fields == nil {
+			fields = map[string]any{}
+		}
+		fields["message"] = msg
+		return o.print(fields, nil, nil)
+	}
+	_, err := fmt.Fprintln(o.w, msg)
+	return err
+}
+
+func fmtTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
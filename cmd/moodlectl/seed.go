@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/yourname/moodle/internal/seed"
+)
+
+func runSeed(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("seed run", flag.ContinueOnError)
+	var c seed.Config
+	fs.Uint64Var(&c.Seed, "seed", 1, "random seed; the same seed gives the same IDs, names and choices")
+	fs.IntVar(&c.Users, "users", 50, "number of users")
+	fs.IntVar(&c.Watchlists, "watchlists", 150, "number of watchlists")
+	fs.IntVar(&c.ItemsPerList, "items", 8, "mean items per watchlist")
+	fs.IntVar(&c.Likes, "likes", 1000, "number of likes")
+	fs.IntVar(&c.Shares, "shares", 100, "number of shares")
+	now := fs.String("now", "", "anchor timestamps to this date or RFC 3339 time instead of the current time, for identical data across runs")
+	clean := fs.Bool("clean", false, "delete previously seeded users first")
+	if _, err := positional(fs, args, 0); err != nil {
+		return err
+	}
+	if *now != "" {
+		t, err := time.Parse(time.RFC3339, *now)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, *now); err != nil {
+				return fmt.Errorf("seed run: -now %q: want a date (2006-01-02) or RFC 3339 time", *now)
+			}
+		}
+		c.Now = t
+	}
+	movies, err := seed.Movies()
+	if err != nil {
+		return err
+	}
+	ds, err := seed.Generate(c, movies)
+	if err != nil {
+		return err
+	}
+	if *clean {
+		if _, err := seed.Clean(ctx, e.store.DB); err != nil {
+			return err
+		}
+	}
+	if err := seed.Insert(ctx, e.store.DB, ds); err != nil {
+		return err
+	}
+	counts := map[string]any{
+		"users": len(ds.Users), "watchlists": len(ds.Watchlists), "items": len(ds.Items),
+		"likes": len(ds.Likes), "shares": len(ds.Shares),
+	}
+	return e.out.message(fmt.Sprintf("seeded %d users, %d watchlists, %d items, %d likes, %d shares",
+		len(ds.Users), len(ds.Watchlists), len(ds.Items), len(ds.Likes), len(ds.Shares)), counts)
+}
+
+func cleanSeed(ctx context.Context, e *env, args []string) error {
+	if _, err := positional(flag.NewFlagSet("seed clean", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+	n, err := seed.Clean(ctx, e.store.DB)
+	if err != nil {
+		return err
+	}
+	return e.out.message(fmt.Sprintf("deleted %d seeded users and their data", n), map[string]any{"users": n})
+}
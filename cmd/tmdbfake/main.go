@@ -0,0 +1,41 @@
+// Command tmdbfake serves the tmdbtest fixtures on a local port so the API
+// and moodlectl can run without TMDb:
+//
+//	go run ./cmd/tmdbfake -addr :8089
+//	TMDB_BASE_URL=http://localhost:8089/3 go run ./cmd/api
+//
+// With -record, requests that have no fixture are fetched from TMDb using
+// TMDB_API_KEY and successful replies saved to -dir with the key removed;
+// add -record-404 to save "not found" replies too.
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/yourname/moodle/internal/tmdb/tmdbtest"
+)
+
+func main() {
+	addr := flag.String("addr", ":8089", "listen address")
+	dir := flag.String("dir", "", "directory of extra fixtures; recorded fixtures are written here")
+	record := flag.Bool("record", false, "fetch and save fixtures that are missing (needs TMDB_API_KEY and -dir)")
+	upstream := flag.String("upstream", tmdbtest.DefaultUpstream, "TMDb base URL to record from")
+	notFound := flag.Bool("record-404", false, "with -record, also save 404 replies")
+	flag.Parse()
+
+	h, err := tmdbtest.NewHandler(tmdbtest.Options{Dir: *dir, Record: *record, RecordNotFound: *notFound, Upstream: *upstream, APIKey: os.Getenv("TMDB_API_KEY")})
+	if err != nil {
+		slog.Error("tmdbfake", "err", err)
+		os.Exit(1)
+	}
+	slog.Info("serving fake TMDb", "addr", *addr, "base_url", "http://localhost"+*addr+"/3", "record", *record)
+	srv := &http.Server{Addr: *addr, Handler: h, ReadHeaderTimeout: 5 * time.Second}
+	if err := srv.ListenAndServe(); err != nil {
+		slog.Error("tmdbfake", "err", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,195 @@
+// Package geminitest is a fake Gemini API for exercising the AI client and
+// handlers offline. Queue the replies a test expects and point the client
+// at the fake:
+//
+//	srv := geminitest.NewServer()
+//	defer srv.Close()
+//	srv.Push(geminitest.Answer("Try Arrival."), geminitest.Blocked("SAFETY"))
+//	client := ai.NewGemini("any-key", "gemini-1.5-flash", srv.URL)
+package geminitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reply is one scripted answer to generateContent.
+type Reply struct {
+	// Text is the answer. Ignored when Status, Block or FinishReason say otherwise.
+	Text string
+	// Status, when not 200, replies with a Google API error of that code.
+	Status int
+	// Block rejects the prompt with this promptFeedback.blockReason.
+	Block string
+	// FinishReason ends the candidate with this reason and no content
+	// when set to e.g. "SAFETY".
+	FinishReason string
+	// Delay holds the reply back, or until the client gives up.
+	Delay time.Duration
+}
+
+// Answer replies with text.
+func Answer(text string) Reply { return Reply{Text: text} }
+
+// Error replies with a Google API error with the given HTTP status.
+func Error(status int) Reply { return Reply{Status: status} }
+
+// Blocked rejects the prompt for reason, e.g. "SAFETY".
+func Blocked(reason string) Reply { return Reply{Block: reason} }
+
+// Slow answers text after d.
+func Slow(d time.Duration, text string) Reply { return Reply{Text: text, Delay: d} }
+
+// Request is a generateContent call the fake received.
+type Request struct {
+	Model  string
+	Prompt string
+}
+
+// Server serves /v1/models/{model} and /v1/models/{model}:generateContent.
+// When the queue is empty it answers with Default.
+type Server struct {
+	// URL is the base URL for ai.NewGemini.
+	URL string
+	// Default is used once the queue runs out.
+	Default Reply
+
+	srv      *httptest.Server
+	mu       sync.Mutex
+	queue    []Reply
+	requests []Request
+}
+
+// NewServer starts a fake that answers "ok" by default; Close it when done.
+func NewServer() *Server {
+	s := &Server{Default: Answer("ok")}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = s.srv.URL + "/v1"
+	return s
+}
+
+// Close stops the server.
+func (s *Server) Close() { s.srv.Close() }
+
+// Push queues replies for the next generateContent calls, in order.
+func (s *Server) Push(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, replies...)
+}
+
+// Requests returns the generateContent calls received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) next() Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return s.Default
+	}
+	r := s.queue[0]
+	s.queue = s.queue[1:]
+	return r
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/models/")
+	if !ok || rest == "" {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Requested entity was not found.")
+		return
+	}
+	if r.URL.Query().Get("key") == "" {
+		writeError(w, http.StatusForbidden, "PERMISSION_DENIED", "Method doesn't allow unregistered callers. Please use API Key or other form of API consumer identity to call this API.")
+		return
+	}
+	model, action, _ := strings.Cut(rest, ":")
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(map[string]string{"name": "models/" + model})
+	case action == "generateContent" && r.Method == http.MethodPost:
+		s.generate(w, r, model)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Requested entity was not found.")
+	}
+}
+
+func (s *Server) generate(w http.ResponseWriter, r *http.Request, model string) {
+	var body struct {
+		Contents []struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"contents"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "Invalid JSON payload received.")
+		return
+	}
+	var prompt []string
+	for _, c := range body.Contents {
+		for _, p := range c.Parts {
+			prompt = append(prompt, p.Text)
+		}
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Model: model, Prompt: strings.Join(prompt, "\n")})
+	s.mu.Unlock()
+
+	reply := s.next()
+	if reply.Delay > 0 {
+		select {
+		case <-time.After(reply.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	switch {
+	case reply.Status != 0 && reply.Status != http.StatusOK:
+		writeError(w, reply.Status, statusName(reply.Status), http.StatusText(reply.Status))
+	case reply.Block != "":
+		_ = json.NewEncoder(w).Encode(map[string]any{"promptFeedback": map[string]string{"blockReason": reply.Block}})
+	case reply.FinishReason != "":
+		_ = json.NewEncoder(w).Encode(map[string]any{"candidates": []any{map[string]any{"finishReason": reply.FinishReason, "index": 0}}})
+	default:
+		_ = json.NewEncoder(w).Encode(map[string]any{"candidates": []any{map[string]any{
+			"content":      map[string]any{"role": "model", "parts": []any{map[string]string{"text": reply.Text}}},
+			"finishReason": "STOP",
+			"index":        0,
+		}}})
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, status, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": code, "message": msg, "status": status}})
+}
+
+// statusName is the google.rpc status Gemini pairs with an HTTP code.
+func statusName(code int) string {
+	switch code {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	default:
+		return "INTERNAL"
+	}
+}
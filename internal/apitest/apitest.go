@@ -0,0 +1,183 @@
+// Package apitest drives the HTTP API end to end: the real router, auth
+// middleware, handlers and Postgres store, with TMDb and Gemini replaced
+// by the tmdbtest and geminitest fakes. Callers authenticate with API
+// tokens, so no Supabase keys are needed:
+//
+//	func TestAPI(t *testing.T) {
+//		apitest.Run(t, func(t *testing.T) *apitest.Env { return apitest.New(t, pgtest.DB(t)) })
+//	}
+//
+// The movie and AI scenarios need no database; RunOffline plays just
+// those against New(t, nil).
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yourname/moodle/internal/ai"
+	"github.com/yourname/moodle/internal/ai/geminitest"
+	"github.com/yourname/moodle/internal/auth"
+	"github.com/yourname/moodle/internal/handlers"
+	httpserver "github.com/yourname/moodle/internal/http"
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/moviecache"
+	"github.com/yourname/moodle/internal/store"
+	"github.com/yourname/moodle/internal/store/memstore"
+	"github.com/yourname/moodle/internal/tmdb"
+	"github.com/yourname/moodle/internal/tmdb/tmdbtest"
+)
+
+// Env is one API server over one database handle.
+type Env struct {
+	Store  *store.Store
+	Movies *moviecache.Cache
+	TMDB   *tmdbtest.Server
+	Gemini *geminitest.Server
+	Server *httptest.Server
+	// AcceptLanguage is sent with every request when set.
+	AcceptLanguage string
+}
+
+// AITimeout is how long the AI client waits for the fake Gemini, kept
+// short so slow-reply scenarios finish quickly.
+const AITimeout = 500 * time.Millisecond
+
+// TMDBRetry keeps the TMDb client's backoff short so retry scenarios finish
+// quickly; a Retry-After above MaxDelay fails the call straight away.
+var TMDBRetry = tmdb.RetryPolicy{Attempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond}
+
+// New serves the movie, TV, AI, watchlist, user and trending routes over db.
+// The servers are closed when t ends.
+func New(t testing.TB, db *gorm.DB) *Env {
+	t.Helper()
+	st := store.New(db)
+	fake, err := tmdbtest.NewServer(tmdbtest.Options{})
+	if err != nil {
+		t.Fatalf("apitest: %v", err)
+	}
+	t.Cleanup(fake.Close)
+	gem := geminitest.NewServer()
+	t.Cleanup(gem.Close)
+	assistant := ai.NewGemini("apitest", "gemini-test", gem.URL)
+	assistant.HTTP.Timeout = AITimeout
+
+	client := tmdb.New("apitest", fake.URL)
+	client.Retry = TMDBRetry
+	// Without a database the movie cache lives in memory.
+	var cached moviecache.Store = st
+	if db == nil {
+		cached = memstore.New()
+	}
+	movies := moviecache.New(client, cached)
+
+	wl := handlers.NewWatchlistHandler(st, movies, nil)
+	aiHandler := handlers.NewAIHandler(assistant)
+	users := handlers.NewUserHandler(st)
+	verifier := &auth.SupabaseVerifier{APITokens: st.UserForAPIToken}
+	srv := httpserver.NewServer(func(r chi.Router) {
+		r.Get("/search/movies", wl.SearchMovies)
+		r.Get("/movies/{id}", wl.Movie)
+		r.Get("/search/multi", wl.SearchMulti)
+		r.Get("/tv/{id}", wl.TV)
+		r.Get("/tv/{id}/season/{season}", wl.TVSeason)
+		r.Get("/feed", wl.Feed)
+		r.Post("/ai/ask", aiHandler.Ask)
+		r.Group(func(r chi.Router) {
+			r.Use(verifier.Middleware)
+			r.Get("/me", users.Me)
+			r.Route("/users", users.Routes)
+			r.Route("/watchlists", wl.Routes)
+			r.Get("/trending", wl.Trending)
+		})
+	})
+	hs := httptest.NewServer(srv.Router)
+	t.Cleanup(hs.Close)
+	return &Env{Store: st, Movies: movies, TMDB: fake, Gemini: gem, Server: hs}
+}
+
+// User creates a user and an API token for it.
+func (e *Env) User(t testing.TB) (id, token string) {
+	t.Helper()
+	name := "apitest-" + uuid.NewString()[:8]
+	u := &models.User{Email: name + "@example.test", Username: name}
+	if err := e.Store.UpsertUser(context.Background(), u); err != nil {
+		t.Fatalf("apitest: create user: %v", err)
+	}
+	raw, err := e.Store.CreateAPIToken(context.Background(), &models.APIToken{UserID: u.ID, Name: "apitest"})
+	if err != nil {
+		t.Fatalf("apitest: create token: %v", err)
+	}
+	return u.ID, raw
+}
+
+// Response is a fully read reply.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Decode unmarshals the body into v.
+func (r *Response) Decode(t testing.TB, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("apitest: decode %s: %v", r.Body, err)
+	}
+}
+
+// Do sends body as JSON to path under /v1, authenticated with token when
+// it is set.
+func (e *Env) Do(t testing.TB, method, path, token string, body any) *Response {
+	t.Helper()
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("apitest: encode body: %v", err)
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, e.Server.URL+"/v1"+path, rd)
+	if err != nil {
+		t.Fatalf("apitest: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if e.AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", e.AcceptLanguage)
+	}
+	res, err := e.Server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("apitest: %s %s: %v", method, path, err)
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("apitest: read %s %s: %v", method, path, err)
+	}
+	return &Response{Status: res.StatusCode, Header: res.Header, Body: b}
+}
+
+// Expect fails t unless the response has the given status.
+func (r *Response) Expect(t testing.TB, status int) *Response {
+	t.Helper()
+	if r.Status != status {
+		t.Fatalf("status %d, want %d: %s", r.Status, status, r.Body)
+	}
+	return r
+}
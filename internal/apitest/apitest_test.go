@@ -0,0 +1,23 @@
+package apitest_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/yourname/moodle/internal/apitest"
+	"github.com/yourname/moodle/internal/store/pgtest"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+// TestOffline needs neither a database nor the network.
+func TestOffline(t *testing.T) {
+	apitest.RunOffline(t, func(t *testing.T) *apitest.Env { return apitest.New(t, nil) })
+}
+
+// TestAPI plays every scenario against Postgres; it is skipped when
+// pgtest cannot find or start one.
+func TestAPI(t *testing.T) {
+	pgtest.Shared(t)
+	apitest.Run(t, func(t *testing.T) *apitest.Env { return apitest.New(t, pgtest.DB(t)) })
+}
@@ -0,0 +1,546 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yourname/moodle/internal/ai/geminitest"
+	"github.com/yourname/moodle/internal/handlers"
+	"github.com/yourname/moodle/internal/metrics"
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/problem"
+	"github.com/yourname/moodle/internal/seed"
+	"github.com/yourname/moodle/internal/tmdb"
+	"github.com/yourname/moodle/internal/tmdb/tmdbtest"
+)
+
+// movieQuery is the query GetMovie sends with every /movie/{id} request.
+const movieQuery = "append_to_response=credits,videos,external_ids"
+
+type scenario struct {
+	name string
+	fn   func(t *testing.T, e *Env)
+}
+
+var offline = []scenario{
+	{"Movies", testMovies},
+	{"MovieNotFound", testMovieNotFound},
+	{"MovieCache", testMovieCache},
+	{"MovieDetails", testMovieDetails},
+	{"TV", testTV},
+	{"Localization", testLocalization},
+	{"WatchProviders", testWatchProviders},
+	{"TMDBRetries", testTMDBRetries},
+	{"TMDBRateLimited", testTMDBRateLimited},
+	{"AskAnswers", testAskAnswers},
+	{"AskBlocked", testAskBlocked},
+	{"AskUpstreamError", testAskUpstreamError},
+	{"AskSlow", testAskSlow},
+}
+
+// Run plays every scenario against servers from newEnv.
+func Run(t *testing.T, newEnv func(t *testing.T) *Env) {
+	run(t, newEnv, append(offline,
+		scenario{"Auth", testAuth},
+		scenario{"WatchlistLifecycle", testWatchlistLifecycle},
+		scenario{"AddUnknownMovie", testAddUnknownMovie},
+		scenario{"FreshItemMetadata", testFreshItemMetadata},
+		scenario{"AddShow", testAddShow},
+		scenario{"Availability", testAvailability},
+		scenario{"PrivateLists", testPrivateLists},
+		scenario{"LikesAndTrending", testLikesAndTrending},
+		scenario{"Blocking", testBlocking},
+	))
+}
+
+// RunOffline plays the scenarios that need no database.
+func RunOffline(t *testing.T, newEnv func(t *testing.T) *Env) {
+	run(t, newEnv, offline)
+}
+
+func run(t *testing.T, newEnv func(t *testing.T) *Env, scenarios []scenario) {
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			s.fn(t, newEnv(t))
+		})
+	}
+}
+
+func createList(t *testing.T, e *Env, token, title string, public bool) models.Watchlist {
+	t.Helper()
+	var wl models.Watchlist
+	e.Do(t, http.MethodPost, "/watchlists", token, map[string]any{"title": title, "is_public": public}).Expect(t, http.StatusCreated).Decode(t, &wl)
+	return wl
+}
+
+func testAuth(t *testing.T, e *Env) {
+	id, token := e.User(t)
+	var me models.User
+	e.Do(t, http.MethodGet, "/me", token, nil).Expect(t, http.StatusOK).Decode(t, &me)
+	if me.ID != id {
+		t.Fatalf("/me = %s, want %s", me.ID, id)
+	}
+	e.Do(t, http.MethodGet, "/me", "", nil).Expect(t, http.StatusUnauthorized)
+	e.Do(t, http.MethodGet, "/me", "mdl_not-a-token", nil).Expect(t, http.StatusUnauthorized)
+}
+
+func testMovies(t *testing.T, e *Env) {
+	var found tmdb.SearchMoviesResponse
+	e.Do(t, http.MethodGet, "/search/movies?q=inception", "", nil).Expect(t, http.StatusOK).Decode(t, &found)
+	if len(found.Results) != 1 || found.Results[0].Title != "Inception" {
+		t.Fatalf("search results = %+v", found.Results)
+	}
+	var mv tmdb.Movie
+	e.Do(t, http.MethodGet, fmt.Sprint("/movies/", found.Results[0].ID), "", nil).Expect(t, http.StatusOK).Decode(t, &mv)
+	if mv.Title != "Inception" {
+		t.Fatalf("movie = %+v", mv)
+	}
+	var feed tmdb.TrendingResponse
+	e.Do(t, http.MethodGet, "/feed?type=trending&window=week", "", nil).Expect(t, http.StatusOK).Decode(t, &feed)
+	if len(feed.Results) == 0 {
+		t.Fatal("trending feed is empty")
+	}
+}
+
+func testMovieNotFound(t *testing.T, e *Env) {
+	var p problem.Problem
+	e.Do(t, http.MethodGet, "/movies/1", "", nil).Expect(t, http.StatusNotFound).Decode(t, &p)
+	if p.Type != problem.TypeNotFound {
+		t.Fatalf("missing movie gave problem %+v", p)
+	}
+}
+
+func testMovieCache(t *testing.T, e *Env) {
+	for i := 0; i < 2; i++ {
+		var mv tmdb.Movie
+		e.Do(t, http.MethodGet, "/movies/27205", "", nil).Expect(t, http.StatusOK).Decode(t, &mv)
+		if mv.Title != "Inception" {
+			t.Fatalf("movie = %+v", mv)
+		}
+	}
+	if n := len(e.TMDB.Requests()); n != 1 {
+		t.Fatalf("TMDb saw %d requests, want 1 with the second read cached", n)
+	}
+
+	// Stale details are served while TMDb is failing.
+	e.Movies.TTL = 0
+	e.TMDB.Queue(tmdbtest.Fixture{Path: "/movie/27205", Query: movieQuery, Status: http.StatusInternalServerError, Body: json.RawMessage(`{}`)})
+	e.Movies.Retry.Attempts = 1
+	var mv tmdb.Movie
+	e.Do(t, http.MethodGet, "/movies/27205", "", nil).Expect(t, http.StatusOK).Decode(t, &mv)
+	if mv.Title != "Inception" {
+		t.Fatalf("stale movie = %+v", mv)
+	}
+}
+
+func testMovieDetails(t *testing.T, e *Env) {
+	var bare tmdb.Movie
+	e.Do(t, http.MethodGet, "/movies/27205", "", nil).Expect(t, http.StatusOK).Decode(t, &bare)
+	if bare.Runtime != 148 || len(bare.Genres) == 0 || bare.Tagline == "" {
+		t.Fatalf("details missing from %+v", bare)
+	}
+	if bare.Credits != nil || bare.Videos != nil || bare.ExternalIDs != nil {
+		t.Fatalf("sections sent without include: %+v", bare)
+	}
+
+	var full tmdb.Movie
+	e.Do(t, http.MethodGet, "/movies/27205?include=credits,videos,external_ids", "", nil).Expect(t, http.StatusOK).Decode(t, &full)
+	if full.Credits == nil || len(full.Credits.Cast) == 0 || full.Credits.Cast[0].Name != "Leonardo DiCaprio" {
+		t.Fatalf("credits = %+v", full.Credits)
+	}
+	if d := full.Credits.Directors(); len(d) != 1 || d[0].Name != "Christopher Nolan" || len(full.Credits.Crew) != 1 {
+		t.Fatalf("crew = %+v, want only the director", full.Credits.Crew)
+	}
+	if full.Videos == nil || len(full.Videos.Results) != 1 || full.Videos.Results[0].Type != "Trailer" {
+		t.Fatalf("videos = %+v, want only the trailer", full.Videos)
+	}
+	if full.ExternalIDs == nil || full.ExternalIDs.IMDbID != "tt1375666" {
+		t.Fatalf("external ids = %+v", full.ExternalIDs)
+	}
+
+	var some tmdb.Movie
+	e.Do(t, http.MethodGet, "/movies/27205?include=external_ids", "", nil).Expect(t, http.StatusOK).Decode(t, &some)
+	if some.ExternalIDs == nil || some.Credits != nil {
+		t.Fatalf("include=external_ids gave %+v", some)
+	}
+	e.Do(t, http.MethodGet, "/movies/27205?include=reviews", "", nil).Expect(t, http.StatusBadRequest)
+}
+
+func testTV(t *testing.T, e *Env) {
+	var found tmdb.SearchMultiResponse
+	e.Do(t, http.MethodGet, "/search/multi?q=breaking%20bad", "", nil).Expect(t, http.StatusOK).Decode(t, &found)
+	if len(found.Results) == 0 || found.Results[0].MediaType != tmdb.MediaTV || found.Results[0].Name != "Breaking Bad" {
+		t.Fatalf("multi search results = %+v", found.Results)
+	}
+	var show tmdb.TVShow
+	e.Do(t, http.MethodGet, fmt.Sprint("/tv/", found.Results[0].ID), "", nil).Expect(t, http.StatusOK).Decode(t, &show)
+	if show.Name != "Breaking Bad" || len(show.Seasons) != show.NumberOfSeasons {
+		t.Fatalf("show = %+v", show)
+	}
+	var season tmdb.Season
+	e.Do(t, http.MethodGet, "/tv/1396/season/1", "", nil).Expect(t, http.StatusOK).Decode(t, &season)
+	if len(season.Episodes) != show.Seasons[0].EpisodeCount {
+		t.Fatalf("season 1 has %d episodes, want %d", len(season.Episodes), show.Seasons[0].EpisodeCount)
+	}
+	e.Do(t, http.MethodGet, "/tv/1", "", nil).Expect(t, http.StatusNotFound)
+
+	var feed tmdb.TrendingTVResponse
+	e.Do(t, http.MethodGet, "/feed?type=trending&media=tv&window=week", "", nil).Expect(t, http.StatusOK).Decode(t, &feed)
+	if len(feed.Results) == 0 || feed.Results[0].Name == "" {
+		t.Fatalf("trending shows = %+v", feed.Results)
+	}
+	e.Do(t, http.MethodGet, "/feed?type=discover&media=tv", "", nil).Expect(t, http.StatusBadRequest)
+}
+
+func testLocalization(t *testing.T, e *Env) {
+	e.TMDB.Set(tmdbtest.Fixture{Path: "/movie/27205", Query: movieQuery + "&language=fr-CA&region=CA", Status: http.StatusOK, Body: json.RawMessage(`{"id":27205,"title":"Origine","release_date":"2010-07-16"}`)})
+	e.TMDB.Set(tmdbtest.Fixture{Path: "/trending/movie/week", Query: "language=fr-CA&region=CA", Status: http.StatusOK, Body: json.RawMessage(`{"page":1,"results":[{"id":27205,"title":"Origine"}]}`)})
+
+	e.AcceptLanguage = "fr-CA, fr;q=0.9, en;q=0.5"
+	var mv tmdb.Movie
+	e.Do(t, http.MethodGet, "/movies/27205", "", nil).Expect(t, http.StatusOK).Decode(t, &mv)
+	if mv.Title != "Origine" {
+		t.Fatalf("localized movie = %+v", mv)
+	}
+	var feed tmdb.TrendingResponse
+	e.Do(t, http.MethodGet, "/feed?type=trending&window=week", "", nil).Expect(t, http.StatusOK).Decode(t, &feed)
+	if len(feed.Results) != 1 || feed.Results[0].Title != "Origine" {
+		t.Fatalf("localized trending = %+v", feed.Results)
+	}
+	var p problem.Problem
+	e.Do(t, http.MethodGet, "/feed?type=bogus", "", nil).Expect(t, http.StatusBadRequest).Decode(t, &p)
+	if p.Title != "Requête incorrecte" || p.Errors["type"] != "doit être l'une des valeurs trending discover" {
+		t.Fatalf("French validation problem = %+v", p)
+	}
+
+	// English callers get their own cache entries and the movies table.
+	e.AcceptLanguage = "en-US"
+	e.Do(t, http.MethodGet, "/movies/27205", "", nil).Expect(t, http.StatusOK).Decode(t, &mv)
+	if mv.Title != "Inception" {
+		t.Fatalf("English movie = %+v", mv)
+	}
+	e.AcceptLanguage = ""
+	e.Do(t, http.MethodGet, "/feed?type=trending&window=week", "", nil).Expect(t, http.StatusOK).Decode(t, &feed)
+	if len(feed.Results) < 2 || feed.Results[0].Title == "Origine" {
+		t.Fatalf("English trending = %+v", feed.Results)
+	}
+	e.AcceptLanguage = "de"
+	e.Do(t, http.MethodGet, "/movies/abc", "", nil).Expect(t, http.StatusBadRequest).Decode(t, &p)
+	if p.Detail != "id muss eine positive ganze Zahl sein" {
+		t.Fatalf("German problem = %+v", p)
+	}
+}
+
+func testWatchProviders(t *testing.T, e *Env) {
+	var mv handlers.MovieResponse
+	e.Do(t, http.MethodGet, "/movies/27205", "", nil).Expect(t, http.StatusOK).Decode(t, &mv)
+	if mv.WatchProviders != nil {
+		t.Fatalf("providers without a region: %+v", mv.WatchProviders)
+	}
+	for i := 0; i < 2; i++ {
+		e.Do(t, http.MethodGet, "/movies/27205?region=us", "", nil).Expect(t, http.StatusOK).Decode(t, &mv)
+		if mv.Title != "Inception" || mv.WatchProviders == nil || len(mv.WatchProviders.Flatrate) == 0 || mv.WatchProviders.Flatrate[0].Name != "Netflix" {
+			t.Fatalf("movie with US providers = %+v", mv)
+		}
+	}
+	if n := strings.Count(strings.Join(e.TMDB.Requests(), " "), "/watch/providers"); n != 1 {
+		t.Fatalf("TMDb saw %d provider requests, want 1 with the second cached", n)
+	}
+	mv = handlers.MovieResponse{}
+	e.Do(t, http.MethodGet, "/movies/27205?region=FR", "", nil).Expect(t, http.StatusOK).Decode(t, &mv)
+	if mv.WatchProviders == nil || len(mv.WatchProviders.Flatrate)+len(mv.WatchProviders.Rent) != 0 {
+		t.Fatalf("providers where the movie is not offered = %+v", mv.WatchProviders)
+	}
+	e.Do(t, http.MethodGet, "/movies/27205?region=USA", "", nil).Expect(t, http.StatusBadRequest)
+}
+
+func testTMDBRetries(t *testing.T, e *Env) {
+	e.TMDB.Queue(
+		tmdbtest.Fixture{Path: "/movie/27205", Query: movieQuery, Status: http.StatusServiceUnavailable, Body: json.RawMessage(`{"status_code":43}`)},
+		tmdbtest.Fixture{Path: "/movie/27205", Query: movieQuery, Status: http.StatusBadGateway, Body: json.RawMessage(`{}`)},
+	)
+	var mv tmdb.Movie
+	e.Do(t, http.MethodGet, "/movies/27205", "", nil).Expect(t, http.StatusOK).Decode(t, &mv)
+	if mv.Title != "Inception" {
+		t.Fatalf("movie = %+v", mv)
+	}
+	if n := len(e.TMDB.Requests()); n != 3 {
+		t.Fatalf("TMDb saw %d requests, want 3", n)
+	}
+}
+
+func testTMDBRateLimited(t *testing.T, e *Env) {
+	e.TMDB.Queue(tmdbtest.Fixture{
+		Path:    "/movie/27205",
+		Query:   movieQuery,
+		Status:  http.StatusTooManyRequests,
+		Headers: map[string]string{"Retry-After": "30"},
+		Body:    json.RawMessage(`{"status_code":25,"status_message":"Your request count is over the allowed limit."}`),
+	})
+	res := e.Do(t, http.MethodGet, "/movies/27205", "", nil).Expect(t, http.StatusServiceUnavailable)
+	if got := res.Header.Get("Retry-After"); got != "30" {
+		t.Fatalf("Retry-After = %q, want 30", got)
+	}
+	if n := len(e.TMDB.Requests()); n != 1 {
+		t.Fatalf("TMDb saw %d requests, want 1 without waiting out Retry-After", n)
+	}
+}
+
+func testAskAnswers(t *testing.T, e *Env) {
+	e.Gemini.Push(geminitest.Answer("Try Arrival."))
+	var res handlers.AskResponse
+	e.Do(t, http.MethodPost, "/ai/ask", "", map[string]string{"query": "something like Interstellar?"}).Expect(t, http.StatusOK).Decode(t, &res)
+	if res.Answer != "Try Arrival." {
+		t.Fatalf("answer = %q", res.Answer)
+	}
+	reqs := e.Gemini.Requests()
+	if len(reqs) != 1 || reqs[0].Prompt != "something like Interstellar?" {
+		t.Fatalf("gemini saw %+v", reqs)
+	}
+	e.Do(t, http.MethodPost, "/ai/ask", "", map[string]string{"query": ""}).Expect(t, http.StatusBadRequest)
+}
+
+func testAskBlocked(t *testing.T, e *Env) {
+	e.Gemini.Push(geminitest.Blocked("SAFETY"), geminitest.Reply{FinishReason: "SAFETY"})
+	for i := 0; i < 2; i++ {
+		var p problem.Problem
+		e.Do(t, http.MethodPost, "/ai/ask", "", map[string]string{"query": "something nasty"}).Expect(t, http.StatusBadRequest).Decode(t, &p)
+		if p.Type != problem.TypeContentRejected {
+			t.Fatalf("blocked reply %d gave problem %+v", i, p)
+		}
+	}
+}
+
+func testAskUpstreamError(t *testing.T, e *Env) {
+	e.Gemini.Push(geminitest.Error(http.StatusTooManyRequests))
+	e.Do(t, http.MethodPost, "/ai/ask", "", map[string]string{"query": "anything"}).Expect(t, http.StatusBadGateway)
+}
+
+func testAskSlow(t *testing.T, e *Env) {
+	e.Gemini.Push(geminitest.Slow(4*AITimeout, "too late"))
+	e.Do(t, http.MethodPost, "/ai/ask", "", map[string]string{"query": "anything"}).Expect(t, http.StatusBadGateway)
+	e.Gemini.Push(geminitest.Slow(AITimeout/5, "just in time"))
+	e.Do(t, http.MethodPost, "/ai/ask", "", map[string]string{"query": "anything"}).Expect(t, http.StatusOK)
+}
+
+func testWatchlistLifecycle(t *testing.T, e *Env) {
+	_, token := e.User(t)
+	movies, err := seed.Movies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wl := createList(t, e, token, "Weekend", true)
+
+	var items []models.WatchlistItem
+	for _, mv := range movies[:3] {
+		var it models.WatchlistItem
+		e.Do(t, http.MethodPost, "/watchlists/"+wl.ID+"/items", token, map[string]any{"tmdb_id": mv.ID}).Expect(t, http.StatusCreated).Decode(t, &it)
+		if it.Title != mv.Title || it.Position != len(items) {
+			t.Fatalf("added %+v, want title %q at position %d", it, mv.Title, len(items))
+		}
+		items = append(items, it)
+	}
+	e.Do(t, http.MethodDelete, "/watchlists/"+wl.ID+"/items/"+items[1].ID, token, nil).Expect(t, http.StatusNoContent)
+	e.Do(t, http.MethodDelete, "/watchlists/"+wl.ID+"/items/"+items[1].ID, token, nil).Expect(t, http.StatusNotFound)
+
+	var got models.Watchlist
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID, token, nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if len(got.Items) != 2 || got.Items[0].ID != items[0].ID || got.Items[1].ID != items[2].ID {
+		t.Fatalf("items after removal = %+v", got.Items)
+	}
+
+	e.Do(t, http.MethodPatch, "/watchlists/"+wl.ID, token, map[string]any{"title": "Renamed"}).Expect(t, http.StatusOK)
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID, token, nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if got.Title != "Renamed" || !got.IsPublic {
+		t.Fatalf("after PATCH got %+v", got)
+	}
+
+	e.Do(t, http.MethodDelete, "/watchlists/"+wl.ID, token, nil).Expect(t, http.StatusNoContent)
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID, token, nil).Expect(t, http.StatusNotFound)
+	e.Do(t, http.MethodDelete, "/watchlists/"+wl.ID, token, nil).Expect(t, http.StatusNotFound)
+}
+
+func testAddUnknownMovie(t *testing.T, e *Env) {
+	_, token := e.User(t)
+	wl := createList(t, e, token, "Typos", false)
+	e.Do(t, http.MethodPost, "/watchlists/"+wl.ID+"/items", token, map[string]any{"tmdb_id": 1}).Expect(t, http.StatusNotFound)
+}
+
+func testFreshItemMetadata(t *testing.T, e *Env) {
+	_, token := e.User(t)
+	wl := createList(t, e, token, "Renames", false)
+	var it models.WatchlistItem
+	e.Do(t, http.MethodPost, "/watchlists/"+wl.ID+"/items", token, map[string]any{"tmdb_id": 27205}).Expect(t, http.StatusCreated).Decode(t, &it)
+
+	cached, err := e.Store.Movie(context.Background(), 27205)
+	if err != nil {
+		t.Fatalf("adding an item did not cache its movie: %v", err)
+	}
+	cached.Title = "Inception (Remastered)"
+	if err := e.Store.SaveMovie(context.Background(), cached); err != nil {
+		t.Fatal(err)
+	}
+	var got models.Watchlist
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID, token, nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if len(got.Items) != 1 || got.Items[0].Title != cached.Title {
+		t.Fatalf("items = %+v, want the cached title", got.Items)
+	}
+}
+
+func testAddShow(t *testing.T, e *Env) {
+	_, token := e.User(t)
+	wl := createList(t, e, token, "Binge", false)
+	var show models.WatchlistItem
+	e.Do(t, http.MethodPost, "/watchlists/"+wl.ID+"/items", token, map[string]any{"tmdb_id": 1396, "media_type": "tv"}).Expect(t, http.StatusCreated).Decode(t, &show)
+	if show.MediaType != models.MediaTV || show.Title != "Breaking Bad" {
+		t.Fatalf("added %+v", show)
+	}
+	var p problem.Problem
+	e.Do(t, http.MethodPost, "/watchlists/"+wl.ID+"/items", token, map[string]any{"tmdb_id": 1396, "media_type": "tv"}).Expect(t, http.StatusConflict).Decode(t, &p)
+	if p.Type != problem.TypeConflict {
+		t.Fatalf("duplicate show gave problem %+v", p)
+	}
+	e.Do(t, http.MethodPost, "/watchlists/"+wl.ID+"/items", token, map[string]any{"tmdb_id": 1396, "media_type": "book"}).Expect(t, http.StatusBadRequest)
+
+	var got models.Watchlist
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID, token, nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if len(got.Items) != 1 || got.Items[0].MediaType != models.MediaTV || got.Items[0].Title != "Breaking Bad" {
+		t.Fatalf("items = %+v", got.Items)
+	}
+}
+
+func testAvailability(t *testing.T, e *Env) {
+	_, token := e.User(t)
+	movies, err := seed.Movies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wl := createList(t, e, token, "Tonight", false)
+	add := func(id int64, media string) string {
+		var it models.WatchlistItem
+		e.Do(t, http.MethodPost, "/watchlists/"+wl.ID+"/items", token, map[string]any{"tmdb_id": id, "media_type": media}).Expect(t, http.StatusCreated).Decode(t, &it)
+		return it.ID
+	}
+	inception, fightClub, breakingBad := add(27205, "movie"), add(550, "movie"), add(1396, "tv")
+	// The fake has no providers for the other seed movies, as if TMDb had
+	// dropped them.
+	var (
+		other   string
+		otherID int64
+	)
+	for _, mv := range movies {
+		if mv.ID != 27205 && mv.ID != 550 {
+			other, otherID = add(mv.ID, "movie"), mv.ID
+			break
+		}
+	}
+
+	var got handlers.Availability
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID+"/availability?region=US", token, nil).Expect(t, http.StatusOK).Decode(t, &got)
+	var names []string
+	for _, s := range got.Services {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "Netflix,Max,Hulu,AMC+" || strings.Join(got.Services[0].Items, ",") != inception+","+breakingBad {
+		t.Fatalf("US services = %+v", got.Services)
+	}
+	if len(got.Unavailable) != 1 || got.Unavailable[0] != other {
+		t.Fatalf("US unavailable = %v, want [%s]", got.Unavailable, other)
+	}
+	if len(got.Unchecked) != 0 {
+		t.Fatalf("US unchecked = %v, want none", got.Unchecked)
+	}
+
+	e.AcceptLanguage = "en-GB"
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID+"/availability", token, nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if got.Region != "GB" || len(got.Services) != 1 || strings.Join(got.Unavailable, ",") != fightClub+","+other {
+		t.Fatalf("GB availability = %+v", got)
+	}
+	e.AcceptLanguage = ""
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID+"/availability", token, nil).Expect(t, http.StatusBadRequest)
+
+	// A rate-limited lookup leaves that item unchecked instead of failing
+	// the list; the others come from the cache.
+	var late string
+	for _, mv := range movies {
+		if mv.ID != 27205 && mv.ID != 550 && mv.ID != otherID {
+			late = add(mv.ID, "movie")
+			e.TMDB.Queue(tmdbtest.Fixture{Path: fmt.Sprintf("/movie/%d/watch/providers", mv.ID), Status: http.StatusTooManyRequests, Headers: map[string]string{"Retry-After": "120"}, Body: json.RawMessage(`{}`)})
+			break
+		}
+	}
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID+"/availability?region=US", token, nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if len(got.Services) != 4 || strings.Join(got.Unchecked, ",") != late {
+		t.Fatalf("availability while rate limited = %+v, want %s unchecked", got, late)
+	}
+
+	_, stranger := e.User(t)
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID+"/availability?region=US", stranger, nil).Expect(t, http.StatusNotFound)
+}
+
+func testPrivateLists(t *testing.T, e *Env) {
+	ownerID, owner := e.User(t)
+	_, other := e.User(t)
+	wl := createList(t, e, owner, "Secret", false)
+
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID, owner, nil).Expect(t, http.StatusOK)
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID, other, nil).Expect(t, http.StatusNotFound)
+	e.Do(t, http.MethodPost, "/watchlists/"+wl.ID+"/like", other, nil).Expect(t, http.StatusNotFound)
+	e.Do(t, http.MethodDelete, "/watchlists/"+wl.ID, other, nil).Expect(t, http.StatusNotFound)
+
+	var lists []models.Watchlist
+	e.Do(t, http.MethodGet, "/watchlists?owner="+ownerID, other, nil).Expect(t, http.StatusOK).Decode(t, &lists)
+	if len(lists) != 0 {
+		t.Fatalf("another user sees %d private lists", len(lists))
+	}
+}
+
+func testLikesAndTrending(t *testing.T, e *Env) {
+	_, owner := e.User(t)
+	_, a := e.User(t)
+	_, b := e.User(t)
+	one, two := createList(t, e, owner, "One like", true), createList(t, e, owner, "Two likes", true)
+
+	// Liking again is a no-op rather than a second like or an error, and
+	// isn't counted as one either.
+	before := testutil.ToFloat64(metrics.Likes)
+	e.Do(t, http.MethodPost, "/watchlists/"+one.ID+"/like", a, nil).Expect(t, http.StatusNoContent)
+	e.Do(t, http.MethodPost, "/watchlists/"+one.ID+"/like", a, nil).Expect(t, http.StatusNoContent)
+	e.Do(t, http.MethodPost, "/watchlists/"+two.ID+"/like", a, nil).Expect(t, http.StatusNoContent)
+	e.Do(t, http.MethodPost, "/watchlists/"+two.ID+"/like", b, nil).Expect(t, http.StatusNoContent)
+	if got := testutil.ToFloat64(metrics.Likes) - before; got != 3 {
+		t.Fatalf("likes_total grew by %v, want 3", got)
+	}
+
+	var top []models.Watchlist
+	e.Do(t, http.MethodGet, "/trending?window=week&limit=100", a, nil).Expect(t, http.StatusOK).Decode(t, &top)
+	var order []string
+	for _, wl := range top {
+		if wl.ID == one.ID || wl.ID == two.ID {
+			order = append(order, wl.ID)
+		}
+	}
+	if len(order) != 2 || order[0] != two.ID {
+		t.Fatalf("trending order %v, want %s before %s", order, two.ID, one.ID)
+	}
+	e.Do(t, http.MethodGet, "/trending?window=fortnight", a, nil).Expect(t, http.StatusBadRequest)
+}
+
+func testBlocking(t *testing.T, e *Env) {
+	_, owner := e.User(t)
+	otherID, other := e.User(t)
+	wl := createList(t, e, owner, "Mine", true)
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID, other, nil).Expect(t, http.StatusOK)
+
+	e.Do(t, http.MethodPost, "/users/"+otherID+"/block", owner, nil).Expect(t, http.StatusNoContent)
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID, other, nil).Expect(t, http.StatusNotFound)
+	e.Do(t, http.MethodPost, "/watchlists/"+wl.ID+"/like", other, nil).Expect(t, http.StatusNotFound)
+
+	e.Do(t, http.MethodDelete, "/users/"+otherID+"/block", owner, nil).Expect(t, http.StatusNoContent)
+	e.Do(t, http.MethodGet, "/watchlists/"+wl.ID, other, nil).Expect(t, http.StatusOK)
+}
@@ -10,6 +10,10 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/yourname/moodle/internal/logging"
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/problem"
 )
 
 type ctxKeyUserID struct{}
@@ -19,8 +23,11 @@ type SupabaseVerifier struct {
 	JWKSURL            string
 	Audience           string
 	Issuer             string
-	parsedKey          *rsa.PublicKey
-	cache              jwksCache
+	// APITokens resolves bearer tokens starting with models.APITokenPrefix
+	// to a user ID; nil turns API tokens off.
+	APITokens func(ctx context.Context, token string) (string, error)
+	parsedKey *rsa.PublicKey
+	cache     jwksCache
 }
 
 func (v *SupabaseVerifier) lazyParse() error {
@@ -106,24 +113,41 @@ func (v *SupabaseVerifier) Middleware(next http.Handler) http.Handler {
 		}
 
 		if tok == "" {
-			w.WriteHeader(http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
+			return
+		}
+
+		if strings.HasPrefix(tok, models.APITokenPrefix) && v.APITokens != nil {
+			uid, err := v.APITokens(r.Context(), tok)
+			if err != nil {
+				problem.Unauthorized(w, r)
+				return
+			}
+			logging.SetUserID(r.Context(), uid)
+			next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), uid)))
 			return
 		}
 
 		parsed, err := jwt.Parse(tok, v.keyFunc, jwt.WithAudience(v.Audience), jwt.WithIssuer(v.Issuer))
 		if err != nil || !parsed.Valid {
-			w.WriteHeader(http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 		if claims, ok := parsed.Claims.(jwt.MapClaims); ok {
 			if sub, ok := claims["sub"].(string); ok && sub != "" {
-				r = r.WithContext(context.WithValue(r.Context(), ctxKeyUserID{}, sub))
+				r = r.WithContext(WithUserID(r.Context(), sub))
+				logging.SetUserID(r.Context(), sub)
 			}
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
+// WithUserID returns ctx authenticated as uid, as the middleware does.
+func WithUserID(ctx context.Context, uid string) context.Context {
+	return context.WithValue(ctx, ctxKeyUserID{}, uid)
+}
+
 func UserID(ctx context.Context) string {
 	if v, ok := ctx.Value(ctxKeyUserID{}).(string); ok {
 		return v
@@ -1,7 +1,9 @@
 package cache
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,6 +16,8 @@ type TTLCache[K comparable, V any] struct {
 	mu   sync.RWMutex
 	data map[K]entry[V]
 	ttl  time.Duration
+
+	hits, misses atomic.Uint64
 }
 
 func NewTTL[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
@@ -25,6 +29,7 @@ func (c *TTLCache[K, V]) Get(k K) (V, bool) {
 	e, ok := c.data[k]
 	c.mu.RUnlock()
 	if !ok {
+		c.misses.Add(1)
 		var zero V
 		return zero, false
 	}
@@ -32,12 +37,19 @@ func (c *TTLCache[K, V]) Get(k K) (V, bool) {
 		c.mu.Lock()
 		delete(c.data, k)
 		c.mu.Unlock()
+		c.misses.Add(1)
 		var zero V
 		return zero, false
 	}
+	c.hits.Add(1)
 	return e.v, true
 }
 
+// Stats returns the number of Get hits and misses so far.
+func (c *TTLCache[K, V]) Stats() (hits, misses uint64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
 func (c *TTLCache[K, V]) Set(k K, v V) {
 	c.mu.Lock()
 	c.data[k] = entry[V]{v: v, exp: time.Now().Add(c.ttl)}
@@ -50,6 +62,27 @@ func (c *TTLCache[K, V]) Delete(k K) {
 	c.mu.Unlock()
 }
 
+// Prune drops expired entries, which Get only removes when they are asked
+// for again. It has the shape of a background job and never fails.
+func (c *TTLCache[K, V]) Prune(context.Context) error {
+	now := time.Now()
+	c.mu.Lock()
+	for k, e := range c.data {
+		if now.After(e.exp) {
+			delete(c.data, k)
+		}
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// Len returns the number of entries, expired ones included.
+func (c *TTLCache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.data)
+}
+
 func (c *TTLCache[K, V]) Clear() {
 	c.mu.Lock()
 	c.data = make(map[K]entry[V])
@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	c := NewTTL[string, int](time.Hour)
+	c.Set("fresh", 1)
+	c.Set("stale", 2)
+	c.data["stale"] = entry[int]{v: 2, exp: time.Now().Add(-time.Second)}
+
+	if err := c.Prune(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := c.Len(); n != 1 {
+		t.Fatalf("Len after Prune = %d, want 1", n)
+	}
+	if v, ok := c.Get("fresh"); !ok || v != 1 {
+		t.Fatalf("Get(fresh) = %v, %v", v, ok)
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 0 {
+		t.Fatalf("Stats = %d hits, %d misses; Prune should not count", hits, misses)
+	}
+}
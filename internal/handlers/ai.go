@@ -2,36 +2,45 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/yourname/moodle/internal/ai"
+	"github.com/yourname/moodle/internal/problem"
 	"github.com/yourname/moodle/internal/validate"
 )
 
-type AIHandler struct{ AI *ai.GeminiClient }
+type AIHandler struct{ AI Assistant }
 
-func NewAIHandler(c *ai.GeminiClient) *AIHandler { return &AIHandler{AI: c} }
+func NewAIHandler(c Assistant) *AIHandler { return &AIHandler{AI: c} }
+
+// AskRequest is the body of POST /v1/ai/ask.
+type AskRequest struct {
+	Query string `json:"query" validate:"required,min=1,max=500"`
+}
+
+type AskResponse struct {
+	Answer string `json:"answer"`
+}
 
 func (h *AIHandler) Ask(w http.ResponseWriter, r *http.Request) {
-	type req struct {
-		Query string `json:"query" validate:"required,min=1,max=500"`
-	}
-	var body req
+	var body AskRequest
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+		problem.InvalidJSON(w, r)
 		return
 	}
-	if errs := validate.Map(body); errs != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(errs)
+	if errs := validate.Map(r.Context(), body); errs != nil {
+		problem.Validation(w, r, errs)
 		return
 	}
 	answer, err := h.AI.Ask(r.Context(), body.Query)
+	if errors.Is(err, ai.ErrBlocked) {
+		contentRejected(w, r, map[string]string{"query": err.Error()})
+		return
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		problem.Upstream(w, r, "gemini", err)
 		return
 	}
-	_ = json.NewEncoder(w).Encode(map[string]string{"answer": answer})
+	_ = json.NewEncoder(w).Encode(AskResponse{Answer: answer})
 }
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"time"
@@ -10,17 +11,17 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/yourname/moodle/internal/auth"
 	"github.com/yourname/moodle/internal/models"
-	"github.com/yourname/moodle/internal/store"
+	"github.com/yourname/moodle/internal/problem"
 )
 
 type AuthHandler struct {
-	Store           *store.Store
+	Store           AuthStore
 	SupabaseURL     string
 	SupabaseAnonKey string
 	ClientURL       string
 }
 
-func NewAuthHandler(store *store.Store, supabaseURL, supabaseAnonKey, clientURL string) *AuthHandler {
+func NewAuthHandler(store AuthStore, supabaseURL, supabaseAnonKey, clientURL string) *AuthHandler {
 	return &AuthHandler{
 		Store:           store,
 		SupabaseURL:     supabaseURL,
@@ -41,15 +42,12 @@ func (h *AuthHandler) Routes(r chi.Router) {
 // GoogleLogin initiates Google OAuth flow via Supabase
 func (h *AuthHandler) googleLogin(w http.ResponseWriter, r *http.Request) {
 	redirectTo := r.URL.Query().Get("redirect_to")
-	fmt.Println("=== GoogleLogin Debug ===")
-	fmt.Println("Raw redirect_to:", redirectTo)
 
 	// URL decode the redirect_to parameter
 	if redirectTo != "" {
 		decoded, err := url.QueryUnescape(redirectTo)
 		if err == nil {
 			redirectTo = decoded
-			fmt.Println("Decoded redirect_to:", redirectTo)
 		}
 	}
 
@@ -57,16 +55,11 @@ func (h *AuthHandler) googleLogin(w http.ResponseWriter, r *http.Request) {
 		redirectTo = h.ClientURL
 	}
 
-	fmt.Println("Final redirect_to:", redirectTo)
-	fmt.Println("h.ClientURL:", h.ClientURL)
-
 	// Build callback URL with the original redirect_to as a parameter
 	callbackURL := fmt.Sprintf("%s/v1/auth/callback", getBaseURL(r))
 	// Always include redirect_to parameter to preserve the original destination
 	callbackURL += "?redirect_to=" + url.QueryEscape(redirectTo)
 
-	fmt.Println("Callback URL:", callbackURL)
-
 	// Build Supabase OAuth URL - redirect to OUR callback, not the final destination
 	authURL := fmt.Sprintf("%s/auth/v1/authorize", h.SupabaseURL)
 	params := url.Values{
@@ -75,8 +68,11 @@ func (h *AuthHandler) googleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	finalURL := authURL + "?" + params.Encode()
-	fmt.Println("Supabase OAuth URL:", finalURL)
-	fmt.Println("=== End Debug ===")
+	slog.DebugContext(r.Context(), "google login redirect",
+		slog.String("redirect_to", redirectTo),
+		slog.String("callback_url", callbackURL),
+		slog.String("oauth_url", finalURL),
+	)
 
 	// Redirect to Supabase Google OAuth
 	http.Redirect(w, r, finalURL, http.StatusTemporaryRedirect)
@@ -95,16 +91,13 @@ func getBaseURL(r *http.Request) string {
 func (h *AuthHandler) authCallback(w http.ResponseWriter, r *http.Request) {
 	// Check for redirect_to parameter from the callback URL
 	redirectTo := r.URL.Query().Get("redirect_to")
-	fmt.Println("=== AuthCallback Debug ===")
-	fmt.Println("Query params:", r.URL.RawQuery)
-	fmt.Println("Callback redirect_to from query:", redirectTo)
-
 	if redirectTo == "" {
 		redirectTo = h.ClientURL
-		fmt.Println("Using default h.ClientURL:", redirectTo)
 	}
-	fmt.Println("Final callback redirect_to:", redirectTo)
-	fmt.Println("=== End Callback Debug ===")
+	slog.DebugContext(r.Context(), "auth callback",
+		slog.String("query", r.URL.RawQuery),
+		slog.String("redirect_to", redirectTo),
+	)
 
 	// Supabase returns tokens in URL fragments, so we need JavaScript to extract them
 	html := fmt.Sprintf(`
@@ -188,33 +181,34 @@ if (error) {
 	_, _ = w.Write([]byte(html))
 }
 
+// AuthCallbackRequest is the body the callback page posts back with the Supabase tokens.
+type AuthCallbackRequest struct {
+	AccessToken  string `json:"access_token" validate:"required"`
+	RefreshToken string `json:"refresh_token"`
+	RedirectTo   string `json:"redirect_to"`
+}
+
 // authCallbackPost handles the POST request from the JavaScript callback
 func (h *AuthHandler) authCallbackPost(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-		RedirectTo   string `json:"redirect_to"`
-	}
+	var req AuthCallbackRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+		problem.InvalidJSON(w, r)
 		return
 	}
 
-	fmt.Println("POST callback - RedirectTo:", req.RedirectTo)
+	slog.DebugContext(r.Context(), "auth callback post", slog.String("redirect_to", req.RedirectTo))
 
 	// Get user info from Supabase
 	user, err := h.getUserFromSupabase(req.AccessToken)
 	if err != nil {
-		fmt.Println("Error getting user from Supabase:", err)
-		http.Error(w, "Failed to get user info", http.StatusInternalServerError)
+		problem.Upstream(w, r, "supabase", err)
 		return
 	}
 
 	// Upsert user in our database
 	if err := h.Store.UpsertUser(r.Context(), user); err != nil {
-		fmt.Println("Error upserting user:", err)
-		http.Error(w, "Failed to save user", http.StatusInternalServerError)
+		problem.Internal(w, r, err)
 		return
 	}
 
@@ -275,13 +269,13 @@ func (h *AuthHandler) logout(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) getUser(w http.ResponseWriter, r *http.Request) {
 	userID := auth.UserID(r.Context())
 	if userID == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		problem.Unauthorized(w, r)
 		return
 	}
 
 	user, err := h.Store.GetUser(r.Context(), userID)
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
+		problem.NotFound(w, r, "user not found")
 		return
 	}
 
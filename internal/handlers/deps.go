@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/yourname/moodle/internal/ai"
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/moviecache"
+	"github.com/yourname/moodle/internal/store"
+	"github.com/yourname/moodle/internal/tmdb"
+)
+
+// The interfaces below list what each handler needs from its
+// dependencies. *store.Store and memstore.Store satisfy the store ones,
+// *tmdb.Client and *moviecache.Cache satisfy MovieSource and
+// *ai.GeminiClient satisfies Assistant.
+
+// WatchlistStore is the persistence WatchlistHandler uses.
+type WatchlistStore interface {
+	GetWatchlist(ctx context.Context, id, viewer string) (*models.Watchlist, error)
+	ListWatchlistsByOwner(ctx context.Context, owner string) ([]models.Watchlist, error)
+	ListPublicWatchlistsByOwner(ctx context.Context, owner, viewer string) ([]models.Watchlist, error)
+	CreateWatchlist(ctx context.Context, wl *models.Watchlist) error
+	UpdateWatchlist(ctx context.Context, wl *models.Watchlist) error
+	DeleteWatchlist(ctx context.Context, id, owner string) error
+	AddItem(ctx context.Context, it *models.WatchlistItem, owner string) error
+	RemoveItem(ctx context.Context, wlID, itemID, owner string) error
+	Like(ctx context.Context, user, wl string) (bool, error)
+	Unlike(ctx context.Context, user, wl string) error
+	ShareWatchlist(ctx context.Context, sh *models.Share) error
+	TopWatchlists(ctx context.Context, viewer, window string, limit int) ([]models.Watchlist, error)
+}
+
+// UserStore is the persistence UserHandler uses.
+type UserStore interface {
+	GetUser(ctx context.Context, id string) (*models.User, error)
+	Block(ctx context.Context, blocker, blocked string) error
+	Unblock(ctx context.Context, blocker, blocked string) error
+	Mute(ctx context.Context, muter, muted string) error
+	Unmute(ctx context.Context, muter, muted string) error
+}
+
+// AuthStore is the persistence AuthHandler uses.
+type AuthStore interface {
+	UpsertUser(ctx context.Context, u *models.User) error
+	GetUser(ctx context.Context, id string) (*models.User, error)
+}
+
+// MovieSource is the TMDb API as WatchlistHandler uses it, for movies and
+// TV shows.
+type MovieSource interface {
+	SearchMovies(ctx context.Context, query string, page int) (*tmdb.SearchMoviesResponse, error)
+	GetMovie(ctx context.Context, id int64) (*tmdb.Movie, error)
+	TrendingMovies(ctx context.Context, window string, page int, region string) (*tmdb.TrendingResponse, error)
+	DiscoverMovies(ctx context.Context, page int, genre, year, region, sortBy string) (*tmdb.DiscoverResponse, error)
+
+	SearchMulti(ctx context.Context, query string, page int) (*tmdb.SearchMultiResponse, error)
+	GetTV(ctx context.Context, id int64) (*tmdb.TVShow, error)
+	GetSeason(ctx context.Context, id int64, season int) (*tmdb.Season, error)
+	TrendingTV(ctx context.Context, window string, page int) (*tmdb.TrendingTVResponse, error)
+
+	MovieWatchProviders(ctx context.Context, id int64) (*tmdb.WatchProviders, error)
+	TVWatchProviders(ctx context.Context, id int64) (*tmdb.WatchProviders, error)
+}
+
+// Assistant answers free-form questions for AIHandler.
+type Assistant interface {
+	Ask(ctx context.Context, prompt string) (string, error)
+}
+
+var (
+	_ WatchlistStore = (*store.Store)(nil)
+	_ UserStore      = (*store.Store)(nil)
+	_ AuthStore      = (*store.Store)(nil)
+	_ MovieSource    = (*tmdb.Client)(nil)
+	_ MovieSource    = (*moviecache.Cache)(nil)
+	_ Assistant      = (*ai.GeminiClient)(nil)
+)
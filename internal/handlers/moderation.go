@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/yourname/moodle/internal/moderation"
+	"github.com/yourname/moodle/internal/problem"
+)
+
+// moderate runs each field through p, rewriting masked text in place. It
+// returns field->message for content the policy rejects and whether any
+// field should be held for review. Classifier failures hold content for
+// review rather than failing the request.
+func moderate(ctx context.Context, p *moderation.Pipeline, fields map[string]*string) (map[string]string, bool) {
+	var rejected map[string]string
+	review := false
+	for name, text := range fields {
+		if text == nil {
+			continue
+		}
+		out, err := p.Check(ctx, *text)
+		if err != nil {
+			slog.WarnContext(ctx, "moderation check failed", slog.String("field", name), slog.Any("err", err))
+			review = true
+			continue
+		}
+		if !out.Flagged {
+			continue
+		}
+		switch out.Action {
+		case moderation.PolicyReject:
+			if rejected == nil {
+				rejected = map[string]string{}
+			}
+			rejected[name] = "contains disallowed content"
+		case moderation.PolicyMask:
+			*text = out.Text
+		case moderation.PolicyReview:
+			*text = out.Text
+			review = true
+		}
+	}
+	return rejected, review
+}
+
+func contentRejected(w http.ResponseWriter, r *http.Request, fields map[string]string) {
+	problem.Write(w, r, problem.Problem{Type: problem.TypeContentRejected, Status: http.StatusBadRequest, Detail: "content was rejected by moderation", Errors: fields})
+}
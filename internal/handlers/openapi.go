@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/openapi"
+	"github.com/yourname/moodle/internal/tmdb"
+)
+
+// APIRoutes describes every route mounted under /v1. Keep it in step with
+// main's mounter; openapi.Verify refuses to boot the server otherwise.
+func APIRoutes() []openapi.Route {
+	intID := map[string]string{"id": "integer"}
+	return []openapi.Route{
+		{Method: "GET", Path: "/openapi.json", ID: "getOpenAPI", Summary: "This document", Tag: "meta"},
+
+		{Method: "GET", Path: "/search/movies", ID: "searchMovies", Summary: "Search TMDb movies", Tag: "movies", Query: SearchQuery{}, Response: tmdb.SearchMoviesResponse{}},
+		{Method: "GET", Path: "/movies/{id}", ID: "getMovie", Summary: "Get a TMDb movie with optional credits, videos, external IDs and watch providers", Tag: "movies", PathTypes: intID, Query: MovieQuery{}, Response: MovieResponse{}},
+		{Method: "GET", Path: "/search/multi", ID: "searchMulti", Summary: "Search TMDb movies, shows and people", Tag: "movies", Query: SearchQuery{}, Response: tmdb.SearchMultiResponse{}},
+		{Method: "GET", Path: "/tv/{id}", ID: "getTV", Summary: "Get a TMDb show with its seasons", Tag: "tv", PathTypes: intID, Response: tmdb.TVShow{}},
+		{Method: "GET", Path: "/tv/{id}/season/{season}", ID: "getTVSeason", Summary: "Get a season of a TMDb show with its episodes", Tag: "tv", PathTypes: map[string]string{"id": "integer", "season": "integer"}, Response: tmdb.Season{}},
+		{Method: "GET", Path: "/feed", ID: "getFeed", Summary: "Trending movie or show feed, or discover movie feed", Tag: "movies", Query: FeedQuery{}, Response: tmdb.TrendingResponse{}},
+		{Method: "POST", Path: "/ai/ask", ID: "askAI", Summary: "Ask the Moodle assistant", Tag: "ai", Body: AskRequest{}, Response: AskResponse{}},
+
+		{Method: "GET", Path: "/auth/google", ID: "googleLogin", Summary: "Start Google sign-in via Supabase", Tag: "auth", Status: http.StatusTemporaryRedirect},
+		{Method: "GET", Path: "/auth/callback", ID: "authCallbackPage", Summary: "OAuth callback page", Tag: "auth"},
+		{Method: "POST", Path: "/auth/callback", ID: "authCallback", Summary: "Exchange Supabase tokens for a session", Tag: "auth", Body: AuthCallbackRequest{}},
+		{Method: "POST", Path: "/auth/logout", ID: "logout", Summary: "Clear session cookies", Tag: "auth"},
+		{Method: "GET", Path: "/auth/user", ID: "authUser", Summary: "Current session user", Tag: "auth", Response: models.User{}},
+
+		{Method: "GET", Path: "/me", ID: "getMe", Summary: "Current user", Tag: "users", Auth: true, Response: models.User{}},
+		{Method: "POST", Path: "/users/{id}/block", ID: "blockUser", Summary: "Block a user", Tag: "users", Auth: true, Status: http.StatusNoContent},
+		{Method: "DELETE", Path: "/users/{id}/block", ID: "unblockUser", Summary: "Unblock a user", Tag: "users", Auth: true, Status: http.StatusNoContent},
+		{Method: "POST", Path: "/users/{id}/mute", ID: "muteUser", Summary: "Mute a user", Tag: "users", Auth: true, Status: http.StatusNoContent},
+		{Method: "DELETE", Path: "/users/{id}/mute", ID: "unmuteUser", Summary: "Unmute a user", Tag: "users", Auth: true, Status: http.StatusNoContent},
+
+		{Method: "GET", Path: "/watchlists", ID: "listWatchlists", Summary: "List a user's watchlists", Tag: "watchlists", Auth: true, Query: ListQuery{}, Response: []models.Watchlist{}},
+		{Method: "POST", Path: "/watchlists", ID: "createWatchlist", Summary: "Create a watchlist", Tag: "watchlists", Auth: true, Body: CreateWatchlistRequest{}, Response: models.Watchlist{}, Status: http.StatusCreated},
+		{Method: "GET", Path: "/watchlists/{id}", ID: "getWatchlist", Summary: "Get a watchlist with its items", Tag: "watchlists", Auth: true, Response: models.Watchlist{}},
+		{Method: "PATCH", Path: "/watchlists/{id}", ID: "updateWatchlist", Summary: "Update a watchlist", Tag: "watchlists", Auth: true, Body: UpdateWatchlistRequest{}, Response: models.Watchlist{}},
+		{Method: "DELETE", Path: "/watchlists/{id}", ID: "deleteWatchlist", Summary: "Delete a watchlist", Tag: "watchlists", Auth: true, Status: http.StatusNoContent},
+		{Method: "POST", Path: "/watchlists/{id}/items", ID: "addWatchlistItem", Summary: "Add a movie or show to a watchlist", Tag: "watchlists", Auth: true, Body: AddItemRequest{}, Response: models.WatchlistItem{}, Status: http.StatusCreated},
+		{Method: "DELETE", Path: "/watchlists/{id}/items/{itemId}", ID: "removeWatchlistItem", Summary: "Remove a movie or show from a watchlist", Tag: "watchlists", Auth: true, Status: http.StatusNoContent},
+		{Method: "GET", Path: "/watchlists/{id}/availability", ID: "watchlistAvailability", Summary: "Group a watchlist's items by the streaming services carrying them", Tag: "watchlists", Auth: true, Query: AvailabilityQuery{}, Response: Availability{}},
+		{Method: "POST", Path: "/watchlists/{id}/like", ID: "likeWatchlist", Summary: "Like a watchlist", Tag: "watchlists", Auth: true, Status: http.StatusNoContent},
+		{Method: "DELETE", Path: "/watchlists/{id}/like", ID: "unlikeWatchlist", Summary: "Unlike a watchlist", Tag: "watchlists", Auth: true, Status: http.StatusNoContent},
+		{Method: "POST", Path: "/watchlists/{id}/share", ID: "shareWatchlist", Summary: "Share a watchlist with a user", Tag: "watchlists", Auth: true, Body: ShareRequest{}, Response: models.Share{}, Status: http.StatusCreated},
+		{Method: "GET", Path: "/trending", ID: "trendingWatchlists", Summary: "Most liked public watchlists", Tag: "watchlists", Auth: true, Query: TrendingQuery{}, Response: []models.Watchlist{}},
+	}
+}
+
+// APISpec builds the OpenAPI document served at /v1/openapi.json.
+func APISpec() *openapi.Document {
+	doc := openapi.Build(openapi.Info{
+		Title:       "Moodle API",
+		Version:     "1",
+		Description: "Create, share, like and discover movie and TV watchlists.",
+	}, APIRoutes())
+	doc.Servers = []openapi.Server{{URL: "/v1"}}
+	return doc
+}
+
+// OpenAPIHandler serves doc as JSON.
+func OpenAPIHandler(doc *openapi.Document) http.HandlerFunc {
+	b, _ := json.Marshal(doc)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
+	"github.com/yourname/moodle/internal/auth"
+	"github.com/yourname/moodle/internal/i18n"
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/problem"
+	"github.com/yourname/moodle/internal/tmdb"
+	"github.com/yourname/moodle/internal/validate"
+)
+
+// providersTTL is how long watch providers are kept; TMDb refreshes them
+// from JustWatch about once a day.
+const providersTTL = 6 * time.Hour
+
+// availabilityWorkers caps the provider lookups one availability request
+// makes at once.
+const availabilityWorkers = 4
+
+// maxAvailabilityLookups caps the TMDb lookups one availability request
+// makes; items already in h.Providers do not count.
+const maxAvailabilityLookups = 40
+
+// errSkipped marks items left past maxAvailabilityLookups.
+var errSkipped = errors.New("availability: lookup skipped")
+
+func providersKey(mediaType string, id int64) string {
+	return mediaType + ":" + strconv.FormatInt(id, 10)
+}
+
+// watchProviders returns where a movie or show can be watched, reading
+// through h.Providers.
+func (h *WatchlistHandler) watchProviders(ctx context.Context, mediaType string, id int64) (*tmdb.WatchProviders, error) {
+	if p, ok := h.Providers.Get(providersKey(mediaType, id)); ok {
+		return p, nil
+	}
+	return h.fetchProviders(ctx, mediaType, id)
+}
+
+// fetchProviders asks TMDb and fills h.Providers. The lists are the same in
+// every language, so TMDb is asked in the default one.
+func (h *WatchlistHandler) fetchProviders(ctx context.Context, mediaType string, id int64) (*tmdb.WatchProviders, error) {
+	ctx = i18n.NewContext(ctx, i18n.Locale{})
+	var (
+		p   *tmdb.WatchProviders
+		err error
+	)
+	if mediaType == models.MediaTV {
+		p, err = h.TMDB.TVWatchProviders(ctx, id)
+	} else {
+		p, err = h.TMDB.MovieWatchProviders(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.Providers.Set(providersKey(mediaType, id), p)
+	return p, nil
+}
+
+// AvailabilityQuery is the query of GET /v1/watchlists/{id}/availability;
+// region defaults to the country in Accept-Language.
+type AvailabilityQuery struct {
+	Region string `query:"region" validate:"omitempty,len=2"`
+}
+
+// Availability is the body of GET /v1/watchlists/{id}/availability.
+type Availability struct {
+	Region string `json:"region"`
+	// Services are the subscription services streaming any of the items,
+	// those carrying the most first.
+	Services []ServiceItems `json:"services"`
+	// Unavailable are the IDs of items no subscription streams in Region.
+	Unavailable []string `json:"unavailable"`
+	// Unchecked are the IDs of items not looked up this time, because the
+	// list is long or TMDb is busy; asking again picks up more of them.
+	Unchecked []string `json:"unchecked"`
+}
+
+// ServiceItems is a streaming service and the IDs of the items it carries.
+type ServiceItems struct {
+	tmdb.Provider
+	Items []string `json:"items"`
+}
+
+// availability: GET /v1/watchlists/{id}/availability?region=
+// Groups a list's items by the subscription services streaming them in
+// region, so the app can show which services cover most of the list.
+func (h *WatchlistHandler) availability(w http.ResponseWriter, r *http.Request) {
+	q := AvailabilityQuery{Region: strings.ToUpper(r.URL.Query().Get("region"))}
+	if q.Region == "" {
+		q.Region = i18n.FromContext(r.Context()).Region
+	}
+	errs := validate.Map(r.Context(), q)
+	if errs == nil && q.Region == "" {
+		errs = map[string]string{"region": i18n.T(r.Context(), "is required")}
+	}
+	if errs != nil {
+		problem.Validation(w, r, errs)
+		return
+	}
+	uid := auth.UserID(r.Context())
+	wl, err := h.Store.GetWatchlist(r.Context(), chi.URLParam(r, "id"), uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.NotFound(w, r, "watchlist not found")
+		} else {
+			problem.Internal(w, r, err)
+		}
+		return
+	}
+	if !wl.IsPublic && wl.OwnerID != uid {
+		problem.NotFound(w, r, "watchlist not found")
+		return
+	}
+
+	// Cached items are answered at once; the rest are looked up a few at a
+	// time, at most maxAvailabilityLookups of them, and anything past that
+	// or turned away by a busy TMDb is reported unchecked.
+	streams := make([][]tmdb.Provider, len(wl.Items))
+	failed := make([]error, len(wl.Items))
+	sem := make(chan struct{}, availabilityWorkers)
+	var wg sync.WaitGroup
+	lookups := 0
+	for i, it := range wl.Items {
+		if p, ok := h.Providers.Get(providersKey(it.MediaType, it.TMDBID)); ok {
+			streams[i] = p.Region(q.Region).Flatrate
+			continue
+		}
+		if lookups == maxAvailabilityLookups {
+			failed[i] = errSkipped
+			continue
+		}
+		lookups++
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			p, err := h.fetchProviders(r.Context(), it.MediaType, it.TMDBID)
+			if err != nil {
+				failed[i] = err
+				return
+			}
+			streams[i] = p.Region(q.Region).Flatrate
+		}()
+	}
+	wg.Wait()
+
+	out := Availability{Region: q.Region, Services: []ServiceItems{}, Unavailable: []string{}, Unchecked: []string{}}
+	byID := map[int64]int{}
+	for i, it := range wl.Items {
+		switch err := failed[i]; {
+		case err == errSkipped, errors.Is(err, tmdb.ErrRateLimited), errors.Is(err, tmdb.ErrUnavailable):
+			out.Unchecked = append(out.Unchecked, it.ID)
+			continue
+		// Titles TMDb no longer has cannot be watched anywhere.
+		case err != nil && !errors.Is(err, tmdb.ErrNotFound):
+			tmdbError(w, r, "title", err)
+			return
+		}
+		if len(streams[i]) == 0 {
+			out.Unavailable = append(out.Unavailable, it.ID)
+			continue
+		}
+		for _, p := range streams[i] {
+			n, ok := byID[p.ID]
+			if !ok {
+				n = len(out.Services)
+				byID[p.ID] = n
+				out.Services = append(out.Services, ServiceItems{Provider: p})
+			}
+			out.Services[n].Items = append(out.Services[n].Items, it.ID)
+		}
+	}
+	slices.SortStableFunc(out.Services, func(a, b ServiceItems) int {
+		return cmp.Or(
+			cmp.Compare(len(b.Items), len(a.Items)),
+			cmp.Compare(a.DisplayPriority, b.DisplayPriority),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	_ = json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/yourname/moodle/internal/problem"
+)
+
+// Public: GET /v1/search/multi?q=&page=
+// Search movies, shows and people at once. Results carry a media_type;
+// movies and shows can be added to watchlists with it.
+func (h *WatchlistHandler) SearchMulti(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		problem.BadRequest(w, r, "q is required")
+		return
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	res, err := h.TMDB.SearchMulti(r.Context(), q, page)
+	if err != nil {
+		tmdbError(w, r, "title", err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// Public: GET /v1/tv/{id}
+// Fetch a show with its season list from TMDb by its numeric ID.
+func (h *WatchlistHandler) TV(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	show, err := h.TMDB.GetTV(r.Context(), id)
+	if err != nil {
+		tmdbError(w, r, "show", err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(show)
+}
+
+// Public: GET /v1/tv/{id}/season/{season}
+// Fetch one season of a show with its episodes. Specials are season 0.
+func (h *WatchlistHandler) TVSeason(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	season, err := strconv.Atoi(chi.URLParam(r, "season"))
+	if err != nil || season < 0 {
+		problem.BadRequest(w, r, "season must be a non-negative integer")
+		return
+	}
+	res, err := h.TMDB.GetSeason(r.Context(), id, season)
+	if err != nil {
+		tmdbError(w, r, "season", err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
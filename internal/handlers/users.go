@@ -1,28 +1,77 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+
 	"github.com/yourname/moodle/internal/auth"
-	"github.com/yourname/moodle/internal/store"
+	"github.com/yourname/moodle/internal/problem"
 )
 
-type UserHandler struct{ Store *store.Store }
+type UserHandler struct{ Store UserStore }
+
+func NewUserHandler(s UserStore) *UserHandler { return &UserHandler{Store: s} }
 
-func NewUserHandler(s *store.Store) *UserHandler { return &UserHandler{Store: s} }
+// Routes is mounted under /users in main.
+func (h *UserHandler) Routes(r chi.Router) {
+	r.Post("/{id}/block", h.block)
+	r.Delete("/{id}/block", h.unblock)
+	r.Post("/{id}/mute", h.mute)
+	r.Delete("/{id}/mute", h.unmute)
+}
 
 func (h *UserHandler) Me(w http.ResponseWriter, r *http.Request) {
 	uid := auth.UserID(r.Context())
 	if uid == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		problem.Unauthorized(w, r)
 		return
 	}
 	u, err := h.Store.GetUser(r.Context(), uid)
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "user not found"})
+		problem.NotFound(w, r, "user not found")
 		return
 	}
 	_ = json.NewEncoder(w).Encode(u)
 }
+
+func (h *UserHandler) block(w http.ResponseWriter, r *http.Request) {
+	h.relate(w, r, h.Store.Block)
+}
+
+func (h *UserHandler) unblock(w http.ResponseWriter, r *http.Request) {
+	h.relate(w, r, h.Store.Unblock)
+}
+
+func (h *UserHandler) mute(w http.ResponseWriter, r *http.Request) {
+	h.relate(w, r, h.Store.Mute)
+}
+
+func (h *UserHandler) unmute(w http.ResponseWriter, r *http.Request) {
+	h.relate(w, r, h.Store.Unmute)
+}
+
+// relate applies a block/mute style change from the current user to {id}.
+func (h *UserHandler) relate(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, from, to string) error) {
+	uid := auth.UserID(r.Context())
+	if uid == "" {
+		problem.Unauthorized(w, r)
+		return
+	}
+	target := chi.URLParam(r, "id")
+	if target == uid {
+		problem.BadRequest(w, r, "cannot target yourself")
+		return
+	}
+	if _, err := h.Store.GetUser(r.Context(), target); err != nil {
+		problem.NotFound(w, r, "user not found")
+		return
+	}
+	if err := fn(r.Context(), uid, target); err != nil {
+		problem.Internal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
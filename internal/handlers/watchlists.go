@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -12,20 +17,27 @@ import (
 
 	"github.com/yourname/moodle/internal/auth"
 	"github.com/yourname/moodle/internal/cache"
+	"github.com/yourname/moodle/internal/i18n"
+	"github.com/yourname/moodle/internal/metrics"
 	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/moderation"
+	"github.com/yourname/moodle/internal/problem"
 	"github.com/yourname/moodle/internal/store"
 	"github.com/yourname/moodle/internal/tmdb"
 	"github.com/yourname/moodle/internal/validate"
 )
 
 type WatchlistHandler struct {
-	Store     *store.Store
-	TMDB      *tmdb.Client
-	FeedCache *cache.TTLCache[string, []byte]
+	Store      WatchlistStore
+	TMDB       MovieSource
+	Moderation *moderation.Pipeline
+	FeedCache  *cache.TTLCache[string, []byte]
+	// Providers holds watch providers by media type and TMDb ID.
+	Providers *cache.TTLCache[string, *tmdb.WatchProviders]
 }
 
-func NewWatchlistHandler(s *store.Store, t *tmdb.Client) *WatchlistHandler {
-	return &WatchlistHandler{Store: s, TMDB: t, FeedCache: cache.NewTTL[string, []byte](60 * time.Second)}
+func NewWatchlistHandler(s WatchlistStore, t MovieSource, m *moderation.Pipeline) *WatchlistHandler {
+	return &WatchlistHandler{Store: s, TMDB: t, Moderation: m, FeedCache: cache.NewTTL[string, []byte](60 * time.Second), Providers: cache.NewTTL[string, *tmdb.WatchProviders](providersTTL)}
 }
 
 // Routes is mounted under /watchlists in main.
@@ -38,75 +50,176 @@ func (h *WatchlistHandler) Routes(r chi.Router) {
 	// items
 	r.Post("/{id}/items", h.addItem)
 	r.Delete("/{id}/items/{itemId}", h.removeItem)
+	r.Get("/{id}/availability", h.availability)
 	// likes
 	r.Post("/{id}/like", h.like)
 	r.Delete("/{id}/like", h.unlike)
+	// shares
+	r.Post("/{id}/share", h.share)
+}
+
+// SearchQuery documents the query of GET /v1/search/movies and /v1/search/multi.
+type SearchQuery struct {
+	Q    string `query:"q" validate:"required"`
+	Page int    `query:"page" validate:"omitempty,gte=1,lte=1000"`
 }
 
 // Public: /v1/search/movies
 func (h *WatchlistHandler) SearchMovies(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query().Get("q")
 	if q == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "q is required"})
+		problem.BadRequest(w, r, "q is required")
 		return
 	}
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	res, err := h.TMDB.SearchMovies(r.Context(), q, page)
 	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		tmdbError(w, r, "movie", err)
 		return
 	}
 	_ = json.NewEncoder(w).Encode(res)
 }
 
-// Public: GET /v1/movies/{id}
-// Fetch a single movie from TMDb by its numeric ID.
+// pathID parses the URL parameter name as a positive integer, answering
+// 400 when it is not one.
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	raw := chi.URLParam(r, name)
+	if raw == "" {
+		problem.BadRequest(w, r, i18n.T(r.Context(), "%s is required", name))
+		return 0, false
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		problem.BadRequest(w, r, i18n.T(r.Context(), "%s must be a positive integer", name))
+		return 0, false
+	}
+	return id, true
+}
+
+// tmdbError answers for a failed TMDb call: 404 when TMDb does not have
+// what (a movie, show or season), 503 with Retry-After while TMDb is
+// throttling us or the client's breaker is open, and 502 otherwise.
+func tmdbError(w http.ResponseWriter, r *http.Request, what string, err error) {
+	switch {
+	case errors.Is(err, tmdb.ErrNotFound):
+		problem.NotFound(w, r, what+" not found")
+	case errors.Is(err, tmdb.ErrRateLimited), errors.Is(err, tmdb.ErrUnavailable):
+		if d, ok := tmdb.RetryAfter(err); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+		}
+		slog.WarnContext(r.Context(), "tmdb unavailable", slog.Any("err", err))
+		problem.Write(w, r, problem.Problem{Type: problem.TypeServiceUnavailable, Status: http.StatusServiceUnavailable, Detail: "movie data is temporarily unavailable"})
+	default:
+		problem.Upstream(w, r, "tmdb", err)
+	}
+}
+
+// MovieQuery documents the query of GET /v1/movies/{id}.
+type MovieQuery struct {
+	// Include is a comma-separated list of credits, videos and external_ids;
+	// the details alone are returned without it.
+	Include string `query:"include"`
+	// Region adds where the movie streams, rents and sells in that country.
+	Region string `query:"region" validate:"omitempty,len=2"`
+}
+
+// MovieResponse is the body of GET /v1/movies/{id}.
+type MovieResponse struct {
+	tmdb.Movie
+	// WatchProviders is set when region is; it is empty when the movie
+	// cannot be watched there and missing when TMDb could not say.
+	WatchProviders *tmdb.CountryProviders `json:"watch_providers,omitempty"`
+}
+
+// movieIncludes are the sections include= can ask for.
+var movieIncludes = []string{"credits", "videos", "external_ids"}
+
+// parseIncludes splits include= into a set, or returns a validation error.
+func parseIncludes(ctx context.Context, raw string) (map[string]bool, map[string]string) {
+	set := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !slices.Contains(movieIncludes, part) {
+			return nil, map[string]string{"include": i18n.T(ctx, "must be a comma-separated list of %s", strings.Join(movieIncludes, ", "))}
+		}
+		set[part] = true
+	}
+	return set, nil
+}
+
+// Public: GET /v1/movies/{id}?include=credits,videos,external_ids&region=US
+// Fetch a single movie from TMDb by its numeric ID. Credits, videos and
+// external IDs are left out unless include asks for them, and watch
+// providers unless region names a country.
 func (h *WatchlistHandler) Movie(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	if idStr == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "id is required"})
+	id, ok := pathID(w, r, "id")
+	if !ok {
 		return
 	}
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil || id <= 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "id must be a positive integer"})
+
+	q := MovieQuery{Include: r.URL.Query().Get("include"), Region: strings.ToUpper(r.URL.Query().Get("region"))}
+	errs := validate.Map(r.Context(), q)
+	var include map[string]bool
+	if errs == nil {
+		include, errs = parseIncludes(r.Context(), q.Include)
+	}
+	if errs != nil {
+		problem.Validation(w, r, errs)
 		return
 	}
 
 	mv, err := h.TMDB.GetMovie(r.Context(), id)
 	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		tmdbError(w, r, "movie", err)
 		return
 	}
-	_ = json.NewEncoder(w).Encode(mv)
+	// Trim a copy; the source may hand out cached values.
+	out := MovieResponse{Movie: *mv}
+	if !include["credits"] {
+		out.Credits = nil
+	}
+	if !include["videos"] {
+		out.Videos = nil
+	}
+	if !include["external_ids"] {
+		out.ExternalIDs = nil
+	}
+	if q.Region != "" {
+		// The details are still worth sending without the providers.
+		if p, err := h.watchProviders(r.Context(), models.MediaMovie, id); err != nil {
+			slog.WarnContext(r.Context(), "watch providers unavailable", slog.Int64("tmdb_id", id), slog.Any("err", err))
+		} else {
+			in := p.Region(q.Region)
+			out.WatchProviders = &in
+		}
+	}
+	_ = json.NewEncoder(w).Encode(out)
 }
 
 // Public (or semi-public): /v1/trending?window=week|month&limit=20
+// TrendingQuery is the query of GET /v1/trending.
+type TrendingQuery struct {
+	Window string `query:"window" validate:"oneof= week month"`
+	Limit  int    `query:"limit" validate:"gte=1,lte=100"`
+}
+
 func (h *WatchlistHandler) Trending(w http.ResponseWriter, r *http.Request) {
-	type qT struct {
-		Window string `validate:"oneof= week month"`
-		Limit  int    `validate:"gte=1,lte=100"`
-	}
-	q := qT{Window: r.URL.Query().Get("window"), Limit: 20}
+	q := TrendingQuery{Window: r.URL.Query().Get("window"), Limit: 20}
 	if v := r.URL.Query().Get("limit"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			q.Limit = n
 		}
 	}
-	if errs := validate.Map(q); errs != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(errs)
+	if errs := validate.Map(r.Context(), q); errs != nil {
+		problem.Validation(w, r, errs)
 		return
 	}
-	lists, err := h.Store.TopWatchlists(r.Context(), q.Window, q.Limit)
+	lists, err := h.Store.TopWatchlists(r.Context(), auth.UserID(r.Context()), q.Window, q.Limit)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		problem.Internal(w, r, err)
 		return
 	}
 	_ = json.NewEncoder(w).Encode(lists)
@@ -114,30 +227,34 @@ func (h *WatchlistHandler) Trending(w http.ResponseWriter, r *http.Request) {
 
 func (h *WatchlistHandler) get(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	wl, err := h.Store.GetWatchlist(r.Context(), id)
+	uid := auth.UserID(r.Context())
+	wl, err := h.Store.GetWatchlist(r.Context(), id, uid)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			w.WriteHeader(http.StatusNotFound)
+			problem.NotFound(w, r, "watchlist not found")
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			problem.Internal(w, r, err)
 		}
 		return
 	}
-	uid := auth.UserID(r.Context())
 	if !wl.IsPublic && wl.OwnerID != uid {
-		w.WriteHeader(http.StatusNotFound)
+		problem.NotFound(w, r, "watchlist not found")
 		return
 	}
 	_ = json.NewEncoder(w).Encode(wl)
 }
 
+// ListQuery documents the query of GET /v1/watchlists; owner defaults to the caller.
+type ListQuery struct {
+	Owner string `query:"owner" validate:"omitempty,uuid"`
+}
+
 func (h *WatchlistHandler) listByOwner(w http.ResponseWriter, r *http.Request) {
 	owner := r.URL.Query().Get("owner")
 	uid := auth.UserID(r.Context())
 	if owner == "" {
 		if uid == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			_ = json.NewEncoder(w).Encode(map[string]string{"error": "owner required"})
+			problem.BadRequest(w, r, "owner required")
 			return
 		}
 		owner = uid
@@ -149,81 +266,95 @@ func (h *WatchlistHandler) listByOwner(w http.ResponseWriter, r *http.Request) {
 	if uid != "" && owner == uid {
 		lists, err = h.Store.ListWatchlistsByOwner(r.Context(), owner)
 	} else {
-		lists, err = h.Store.ListPublicWatchlistsByOwner(r.Context(), owner)
+		lists, err = h.Store.ListPublicWatchlistsByOwner(r.Context(), owner, uid)
 	}
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		problem.Internal(w, r, err)
 		return
 	}
 	_ = json.NewEncoder(w).Encode(lists)
 }
 
+// CreateWatchlistRequest is the body of POST /v1/watchlists.
+type CreateWatchlistRequest struct {
+	Title       string `json:"title" validate:"required,min=1,max=200"`
+	Description string `json:"description" validate:"max=1000"`
+	IsPublic    bool   `json:"is_public"`
+}
+
 func (h *WatchlistHandler) create(w http.ResponseWriter, r *http.Request) {
 	uid := auth.UserID(r.Context())
 	if uid == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		problem.Unauthorized(w, r)
 		return
 	}
-	type bodyT struct {
-		Title       string `validate:"required,min=1,max=200"`
-		Description string `validate:"max=1000"`
-		IsPublic    bool
-	}
-	var b bodyT
+	var b CreateWatchlistRequest
 	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		problem.InvalidJSON(w, r)
+		return
+	}
+	if errs := validate.Map(r.Context(), b); errs != nil {
+		problem.Validation(w, r, errs)
 		return
 	}
-	if errs := validate.Map(b); errs != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(errs)
+	rejected, review := moderate(r.Context(), h.Moderation, map[string]*string{"title": &b.Title, "description": &b.Description})
+	if rejected != nil {
+		contentRejected(w, r, rejected)
 		return
 	}
-	wl := &models.Watchlist{OwnerID: uid, Title: b.Title, Description: b.Description, IsPublic: b.IsPublic}
+	wl := &models.Watchlist{OwnerID: uid, Title: b.Title, Description: b.Description, IsPublic: b.IsPublic, ModerationStatus: models.ModerationOK}
+	if review {
+		wl.ModerationStatus = models.ModerationPendingReview
+	}
 	if err := h.Store.CreateWatchlist(r.Context(), wl); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		problem.Internal(w, r, err)
 		return
 	}
+	metrics.WatchlistsCreated.Inc()
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(wl)
 }
 
+// UpdateWatchlistRequest is the body of PATCH /v1/watchlists/{id}; omitted fields are unchanged.
+type UpdateWatchlistRequest struct {
+	Title       *string `json:"title" validate:"omitempty,min=1,max=200"`
+	Description *string `json:"description" validate:"omitempty,max=1000"`
+	IsPublic    *bool   `json:"is_public"`
+}
+
 func (h *WatchlistHandler) update(w http.ResponseWriter, r *http.Request) {
 	uid := auth.UserID(r.Context())
 	if uid == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		problem.Unauthorized(w, r)
 		return
 	}
 	id := chi.URLParam(r, "id")
-	type bodyT struct {
-		Title       *string `validate:"omitempty,min=1,max=200"`
-		Description *string `validate:"omitempty,max=1000"`
-		IsPublic    *bool
-	}
-	var b bodyT
+	var b UpdateWatchlistRequest
 	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		problem.InvalidJSON(w, r)
 		return
 	}
-	if errs := validate.Map(b); errs != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(errs)
+	if errs := validate.Map(r.Context(), b); errs != nil {
+		problem.Validation(w, r, errs)
+		return
+	}
+	rejected, review := moderate(r.Context(), h.Moderation, map[string]*string{"title": b.Title, "description": b.Description})
+	if rejected != nil {
+		contentRejected(w, r, rejected)
 		return
 	}
 	// fetch existing to merge
-	existing, err := h.Store.GetWatchlist(r.Context(), id)
+	existing, err := h.Store.GetWatchlist(r.Context(), id, uid)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			w.WriteHeader(http.StatusNotFound)
+			problem.NotFound(w, r, "watchlist not found")
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			problem.Internal(w, r, err)
 		}
 		return
 	}
 	if existing.OwnerID != uid {
-		w.WriteHeader(http.StatusForbidden)
+		problem.Forbidden(w, r, "only the owner can edit this watchlist")
 		return
 	}
 	if b.Title != nil {
@@ -235,9 +366,14 @@ func (h *WatchlistHandler) update(w http.ResponseWriter, r *http.Request) {
 	if b.IsPublic != nil {
 		existing.IsPublic = *b.IsPublic
 	}
+	// Flagged text waits for a moderator, and so does a rejected list its
+	// owner has rewritten; a pending list stays queued whatever the edit.
+	rewritten := b.Title != nil || b.Description != nil
+	if review || (rewritten && existing.ModerationStatus == models.ModerationRejected) {
+		existing.ModerationStatus = models.ModerationPendingReview
+	}
 	if err := h.Store.UpdateWatchlist(r.Context(), existing); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		problem.Internal(w, r, err)
 		return
 	}
 	_ = json.NewEncoder(w).Encode(existing)
@@ -246,52 +382,81 @@ func (h *WatchlistHandler) update(w http.ResponseWriter, r *http.Request) {
 func (h *WatchlistHandler) delete(w http.ResponseWriter, r *http.Request) {
 	uid := auth.UserID(r.Context())
 	if uid == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		problem.Unauthorized(w, r)
 		return
 	}
 	id := chi.URLParam(r, "id")
 	if err := h.Store.DeleteWatchlist(r.Context(), id, uid); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			w.WriteHeader(http.StatusNotFound)
+			problem.NotFound(w, r, "watchlist not found")
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			problem.Internal(w, r, err)
 		}
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// AddItemRequest is the body of POST /v1/watchlists/{id}/items;
+// media_type defaults to movie.
+type AddItemRequest struct {
+	TMDBID    int64  `json:"tmdb_id" validate:"required,gt=0"`
+	MediaType string `json:"media_type" validate:"omitempty,oneof=movie tv"`
+	Notes     string `json:"notes" validate:"max=1000"`
+}
+
 func (h *WatchlistHandler) addItem(w http.ResponseWriter, r *http.Request) {
 	uid := auth.UserID(r.Context())
 	if uid == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		problem.Unauthorized(w, r)
 		return
 	}
 	wlID := chi.URLParam(r, "id")
-	type bodyT struct {
-		TMDBID int64  `json:"tmdb_id" validate:"required,gt=0"`
-		Notes  string `json:"notes" validate:"max=1000"`
-	}
-	var b bodyT
+	var b AddItemRequest
 	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		problem.InvalidJSON(w, r)
 		return
 	}
-	if errs := validate.Map(b); errs != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(errs)
+	if errs := validate.Map(r.Context(), b); errs != nil {
+		problem.Validation(w, r, errs)
 		return
 	}
-	mv, err := h.TMDB.GetMovie(r.Context(), b.TMDBID)
-	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	rejected, review := moderate(r.Context(), h.Moderation, map[string]*string{"notes": &b.Notes})
+	if rejected != nil {
+		contentRejected(w, r, rejected)
 		return
 	}
-	item := &models.WatchlistItem{WatchlistID: wlID, TMDBID: b.TMDBID, Title: mv.Title, PosterPath: mv.PosterPath, ReleaseDate: mv.ReleaseDate, Notes: b.Notes}
+	// Lists are shared, so items keep TMDb's default (English) metadata
+	// whatever language the caller reads in.
+	ctx := i18n.NewContext(r.Context(), i18n.Locale{})
+	item := &models.WatchlistItem{WatchlistID: wlID, MediaType: models.MediaMovie, TMDBID: b.TMDBID, Notes: b.Notes, ModerationStatus: models.ModerationOK}
+	if b.MediaType == models.MediaTV {
+		show, err := h.TMDB.GetTV(ctx, b.TMDBID)
+		if err != nil {
+			tmdbError(w, r, "show", err)
+			return
+		}
+		item.MediaType, item.Title, item.PosterPath, item.ReleaseDate = models.MediaTV, show.Name, show.PosterPath, show.FirstAirDate
+	} else {
+		mv, err := h.TMDB.GetMovie(ctx, b.TMDBID)
+		if err != nil {
+			tmdbError(w, r, "movie", err)
+			return
+		}
+		item.Title, item.PosterPath, item.ReleaseDate = mv.Title, mv.PosterPath, mv.ReleaseDate
+	}
+	if review {
+		item.ModerationStatus = models.ModerationPendingReview
+	}
 	if err := h.Store.AddItem(r.Context(), item, uid); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			problem.NotFound(w, r, "watchlist not found")
+		case errors.Is(err, store.ErrDuplicateItem):
+			problem.Write(w, r, problem.Problem{Type: problem.TypeConflict, Status: http.StatusConflict, Detail: "this title is already on the watchlist"})
+		default:
+			problem.Internal(w, r, err)
+		}
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
@@ -301,16 +466,16 @@ func (h *WatchlistHandler) addItem(w http.ResponseWriter, r *http.Request) {
 func (h *WatchlistHandler) removeItem(w http.ResponseWriter, r *http.Request) {
 	uid := auth.UserID(r.Context())
 	if uid == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		problem.Unauthorized(w, r)
 		return
 	}
 	wlID := chi.URLParam(r, "id")
 	itemID := chi.URLParam(r, "itemId")
 	if err := h.Store.RemoveItem(r.Context(), wlID, itemID, uid); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			w.WriteHeader(http.StatusNotFound)
+			problem.NotFound(w, r, "watchlist or item not found")
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			problem.Internal(w, r, err)
 		}
 		return
 	}
@@ -320,47 +485,97 @@ func (h *WatchlistHandler) removeItem(w http.ResponseWriter, r *http.Request) {
 func (h *WatchlistHandler) like(w http.ResponseWriter, r *http.Request) {
 	uid := auth.UserID(r.Context())
 	if uid == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		problem.Unauthorized(w, r)
 		return
 	}
 	wlID := chi.URLParam(r, "id")
-	if err := h.Store.Like(r.Context(), uid, wlID); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	created, err := h.Store.Like(r.Context(), uid, wlID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.NotFound(w, r, "watchlist not found")
+			return
+		}
+		problem.Internal(w, r, err)
 		return
 	}
+	if created {
+		metrics.Likes.Inc()
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *WatchlistHandler) unlike(w http.ResponseWriter, r *http.Request) {
 	uid := auth.UserID(r.Context())
 	if uid == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		problem.Unauthorized(w, r)
 		return
 	}
 	wlID := chi.URLParam(r, "id")
 	if err := h.Store.Unlike(r.Context(), uid, wlID); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		problem.Internal(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// Feed: GET /v1/feed?type=trending|discover&window=day|week&page=1&genre=&year=&region=&sort_by=
-// type=trending uses TMDb trending; type=discover uses TMDb discover with filters.
+// ShareRequest is the body of POST /v1/watchlists/{id}/share.
+type ShareRequest struct {
+	ToUserID string `json:"to_user_id" validate:"required,uuid"`
+	Message  string `json:"message" validate:"max=500"`
+}
+
+func (h *WatchlistHandler) share(w http.ResponseWriter, r *http.Request) {
+	uid := auth.UserID(r.Context())
+	if uid == "" {
+		problem.Unauthorized(w, r)
+		return
+	}
+	wlID := chi.URLParam(r, "id")
+	var b ShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		problem.InvalidJSON(w, r)
+		return
+	}
+	if errs := validate.Map(r.Context(), b); errs != nil {
+		problem.Validation(w, r, errs)
+		return
+	}
+	sh := &models.Share{FromUserID: uid, ToUserID: b.ToUserID, WatchlistID: wlID, Message: b.Message}
+	if err := h.Store.ShareWatchlist(r.Context(), sh); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			problem.NotFound(w, r, "watchlist not found")
+		case errors.Is(err, store.ErrBlocked):
+			problem.Forbidden(w, r, "cannot share with this user")
+		default:
+			problem.Internal(w, r, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(sh)
+}
+
+// FeedQuery is the query of GET /v1/feed.
+type FeedQuery struct {
+	Type string `query:"type" validate:"required,oneof=trending discover"`
+	// Media picks movies (the default) or shows; shows only trend.
+	Media  string `query:"media" validate:"omitempty,oneof=movie tv"`
+	Window string `query:"window" validate:"omitempty,oneof=day week"`
+	Page   int    `query:"page" validate:"omitempty,gte=1,lte=1000"`
+	Genre  string `query:"genre" validate:"omitempty"`
+	Year   string `query:"year" validate:"omitempty"`
+	Region string `query:"region" validate:"omitempty,len=2"`
+	SortBy string `query:"sort_by" validate:"omitempty,oneof=popularity.desc vote_average.desc release_date.desc"`
+}
+
+// Feed: GET /v1/feed?type=trending|discover&media=movie|tv&window=day|week&page=1&genre=&year=&region=&sort_by=
+// type=trending uses TMDb trending, of shows with media=tv; type=discover
+// uses TMDb movie discover with filters.
 func (h *WatchlistHandler) Feed(w http.ResponseWriter, r *http.Request) {
-	type qT struct {
-		Type   string `validate:"required,oneof=trending discover"`
-		Window string `validate:"omitempty,oneof=day week"`
-		Page   int    `validate:"omitempty,gte=1,lte=1000"`
-		Genre  string `validate:"omitempty"`
-		Year   string `validate:"omitempty"`
-		Region string `validate:"omitempty,len=2"`
-		SortBy string `validate:"omitempty,oneof=popularity.desc vote_average.desc release_date.desc"`
-	}
-	q := qT{
+	q := FeedQuery{
 		Type:   r.URL.Query().Get("type"),
+		Media:  r.URL.Query().Get("media"),
 		Window: r.URL.Query().Get("window"),
 		Genre:  r.URL.Query().Get("genre"),
 		Year:   r.URL.Query().Get("year"),
@@ -372,16 +587,17 @@ func (h *WatchlistHandler) Feed(w http.ResponseWriter, r *http.Request) {
 			q.Page = n
 		}
 	}
-	if errs := validate.Map(q); errs != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(errs)
-		return
+	errs := validate.Map(r.Context(), q)
+	if errs == nil && q.Media == models.MediaTV && q.Type != "trending" {
+		errs = map[string]string{"media": "tv is only available with type=trending"}
 	}
-	// Build cache key from query
-	key := r.URL.RawQuery
-	if key == "" {
-		key = "_empty"
+	if errs != nil {
+		problem.Validation(w, r, errs)
+		return
 	}
+	// Build cache key from the locale TMDb is asked for and the query
+	loc := i18n.FromContext(r.Context())
+	key := loc.Language + "|" + loc.Region + "|" + r.URL.RawQuery
 	if b, ok := h.FeedCache.Get(key); ok {
 		w.Header().Set("Cache-Control", "public, max-age=60")
 		w.WriteHeader(http.StatusOK)
@@ -390,10 +606,17 @@ func (h *WatchlistHandler) Feed(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if q.Type == "trending" {
-		res, err := h.TMDB.TrendingMovies(r.Context(), q.Window, q.Page, q.Region)
+		var res any
+		var err error
+		what := "movie"
+		if q.Media == models.MediaTV {
+			what = "show"
+			res, err = h.TMDB.TrendingTV(r.Context(), q.Window, q.Page)
+		} else {
+			res, err = h.TMDB.TrendingMovies(r.Context(), q.Window, q.Page, q.Region)
+		}
 		if err != nil {
-			w.WriteHeader(http.StatusBadGateway)
-			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			tmdbError(w, r, what, err)
 			return
 		}
 		b, _ := json.Marshal(res)
@@ -406,8 +629,7 @@ func (h *WatchlistHandler) Feed(w http.ResponseWriter, r *http.Request) {
 	// discover
 	res, err := h.TMDB.DiscoverMovies(r.Context(), q.Page, q.Genre, q.Year, q.Region, q.SortBy)
 	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		tmdbError(w, r, "movie", err)
 		return
 	}
 	b, _ := json.Marshal(res)
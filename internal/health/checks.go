@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DB pings the connection pool.
+func DB(db *sql.DB) CheckFunc {
+	return db.PingContext
+}
+
+var migrationFile = regexp.MustCompile(`^(\d+)_.+\.sql$`)
+
+// Migrations fails while any goose migration in fsys has not been applied
+// according to table (goose_db_version by default).
+func Migrations(db *sql.DB, fsys fs.FS, table string) CheckFunc {
+	if table == "" {
+		table = "goose_db_version"
+	}
+	return func(ctx context.Context) error {
+		entries, err := fs.ReadDir(fsys, ".")
+		if err != nil {
+			return err
+		}
+		rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT version_id FROM %q", table))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		applied := map[int64]bool{}
+		for rows.Next() {
+			var v int64
+			if err := rows.Scan(&v); err != nil {
+				return err
+			}
+			applied[v] = true
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		var pending []string
+		for _, e := range entries {
+			m := migrationFile.FindStringSubmatch(e.Name())
+			if m == nil {
+				continue
+			}
+			v, _ := strconv.ParseInt(m[1], 10, 64)
+			if !applied[v] {
+				pending = append(pending, e.Name())
+			}
+		}
+		if len(pending) > 0 {
+			sort.Strings(pending)
+			return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+		}
+		return nil
+	}
+}
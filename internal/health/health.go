@@ -0,0 +1,143 @@
+// Package health runs dependency checks for the readiness endpoint and
+// reports them as application/health+json
+// (draft-inadvisable-health-check-api-06).
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Status values from the health+json draft.
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// ContentType is the media type of health responses.
+const ContentType = "application/health+json"
+
+// CheckFunc returns nil when the dependency is healthy.
+type CheckFunc func(ctx context.Context) error
+
+// Check is one registered dependency check.
+type Check struct {
+	Name string
+	Fn   CheckFunc
+	// Timeout bounds a single run; 2s when zero.
+	Timeout time.Duration
+	// CacheTTL reuses the last result for this long, for checks that cost
+	// money or quota. Zero runs the check on every request.
+	CacheTTL time.Duration
+	// Optional checks report warn instead of fail and never fail readiness.
+	Optional bool
+}
+
+// Result is one check's entry in the response, in health+json form.
+// /readyz is public, so it carries no error text; run logs why a check
+// failed.
+type Result struct {
+	Status        string    `json:"status"`
+	ObservedValue int64     `json:"observedValue"`
+	ObservedUnit  string    `json:"observedUnit"`
+	Time          time.Time `json:"time"`
+}
+
+// Report is the full response body.
+type Report struct {
+	Status string `json:"status"`
+	Output string `json:"output,omitempty"`
+	// Checks is keyed "<name>:responseTime" as the draft suggests.
+	Checks map[string][]Result `json:"checks,omitempty"`
+}
+
+type cached struct {
+	res Result
+	exp time.Time
+}
+
+// Registry holds the checks run by /readyz.
+type Registry struct {
+	mu     sync.Mutex
+	checks []Check
+	cache  map[string]cached
+}
+
+func NewRegistry() *Registry {
+	return &Registry{cache: map[string]cached{}}
+}
+
+func (r *Registry) Register(c Check) {
+	if c.Timeout == 0 {
+		c.Timeout = 2 * time.Second
+	}
+	r.mu.Lock()
+	r.checks = append(r.checks, c)
+	r.mu.Unlock()
+}
+
+// Run executes every check concurrently and aggregates the results: fail
+// if any required check fails, warn if only optional ones do.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.Lock()
+	checks := append([]Check(nil), r.checks...)
+	r.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c Check) {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	rep := Report{Status: StatusPass, Checks: map[string][]Result{}}
+	for i, c := range checks {
+		res := results[i]
+		rep.Checks[c.Name+":responseTime"] = []Result{res}
+		switch {
+		case res.Status == StatusFail:
+			rep.Status = StatusFail
+		case res.Status == StatusWarn && rep.Status == StatusPass:
+			rep.Status = StatusWarn
+		}
+	}
+	return rep
+}
+
+func (r *Registry) run(ctx context.Context, c Check) Result {
+	if c.CacheTTL > 0 {
+		r.mu.Lock()
+		hit, ok := r.cache[c.Name]
+		r.mu.Unlock()
+		if ok && time.Now().Before(hit.exp) {
+			return hit.res
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	start := time.Now()
+	err := c.Fn(ctx)
+	took := time.Since(start)
+	res := Result{Status: StatusPass, ObservedValue: took.Milliseconds(), ObservedUnit: "ms", Time: start.UTC()}
+	if err != nil {
+		res.Status = StatusFail
+		if c.Optional {
+			res.Status = StatusWarn
+		}
+		slog.WarnContext(ctx, "health check failed", slog.String("check", c.Name), slog.Bool("optional", c.Optional),
+			slog.Duration("duration", took), slog.Any("err", err))
+	}
+	if c.CacheTTL > 0 {
+		r.mu.Lock()
+		r.cache[c.Name] = cached{res: res, exp: time.Now().Add(c.CacheTTL)}
+		r.mu.Unlock()
+	}
+	return res
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunHidesErrors(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	secret := errors.New("dial tcp 10.0.0.7:5432: password authentication failed for user admin")
+	reg := NewRegistry()
+	reg.Register(Check{Name: "postgres", Fn: func(context.Context) error { time.Sleep(5 * time.Millisecond); return nil }})
+	reg.Register(Check{Name: "tmdb", Fn: func(context.Context) error { return secret }, Optional: true})
+
+	rep := reg.Run(context.Background())
+	if rep.Status != StatusWarn || rep.Checks["postgres:responseTime"][0].Status != StatusPass || rep.Checks["tmdb:responseTime"][0].Status != StatusWarn {
+		t.Fatalf("report = %+v", rep)
+	}
+
+	reg.Register(Check{Name: "migrations", Fn: func(context.Context) error { return secret }})
+	rep = reg.Run(context.Background())
+	if rep.Status != StatusFail || rep.Checks["migrations:responseTime"][0].Status != StatusFail {
+		t.Fatalf("report = %+v", rep)
+	}
+	pg := rep.Checks["postgres:responseTime"][0]
+	if pg.ObservedUnit != "ms" || pg.ObservedValue < 5 || pg.Time.IsZero() {
+		t.Fatalf("postgres latency = %+v, want at least 5ms", pg)
+	}
+	body, err := json.Marshal(rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "10.0.0.7") || strings.Contains(string(body), "password") {
+		t.Fatalf("report leaks the error: %s", body)
+	}
+	if !strings.Contains(logs.String(), "check=migrations") || !strings.Contains(logs.String(), "10.0.0.7") {
+		t.Fatalf("failure not logged:\n%s", logs.String())
+	}
+}
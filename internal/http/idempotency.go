@@ -0,0 +1,224 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yourname/moodle/internal/problem"
+)
+
+// StoredResponse is a response saved under an Idempotency-Key.
+type StoredResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyRecord is the state of one key. Response is nil while the
+// first request is still running.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Response    *StoredResponse
+}
+
+// IdempotencyBackend stores keys. Begin reserves key for fingerprint and
+// returns nil, or returns the record already held under key.
+type IdempotencyBackend interface {
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, resp StoredResponse) error
+	Release(ctx context.Context, key string) error
+}
+
+// maxIdempotentBody bounds how much request body is read for the fingerprint.
+const maxIdempotentBody = 1 << 20
+
+// Idempotency replays the stored response when a POST, PATCH or DELETE is
+// retried with the same Idempotency-Key and body. A key reused with a
+// different request gets 422; a retry racing the original gets 409. Keys
+// are scoped per caller, so mount it after the verifier. 5xx responses,
+// panics and handlers that write nothing are not stored so the client can
+// retry them. Only the replayHeaders are replayed.
+func Idempotency(b IdempotencyBackend, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch && r.Method != http.MethodDelete) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > 255 {
+				problem.BadRequest(w, r, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil {
+				problem.BadRequest(w, r, "could not read request body")
+				return
+			}
+			if len(body) > maxIdempotentBody {
+				problem.Write(w, r, problem.Problem{Type: problem.TypePayloadTooLarge, Status: http.StatusRequestEntityTooLarge, Detail: "body too large for an Idempotency-Key request"})
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := rateKey(r) + ":" + key
+			fp := fingerprint(r, body)
+			rec, err := b.Begin(r.Context(), storeKey, fp, ttl)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "idempotency begin failed", slog.Any("err", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			if rec != nil {
+				switch {
+				case rec.Fingerprint != fp:
+					problem.Write(w, r, problem.Problem{Type: problem.TypeUnprocessable, Status: http.StatusUnprocessableEntity, Detail: "Idempotency-Key was reused with a different request"})
+				case rec.Response == nil:
+					problem.Write(w, r, problem.Problem{Type: problem.TypeConflict, Status: http.StatusConflict, Detail: "a request with this Idempotency-Key is in progress"})
+				default:
+					replay(w, rec.Response)
+				}
+				return
+			}
+
+			cw := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				// A handler that panicked or never answered has nothing
+				// worth replaying; free the key so the client can retry.
+				p := recover()
+				// Use a fresh context: the request's may already be cancelled.
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if p != nil || !cw.wroteHeader || cw.status >= 500 {
+					err = b.Release(ctx, storeKey)
+				} else {
+					err = b.Complete(ctx, storeKey, StoredResponse{Status: cw.status, Header: replayable(w.Header()), Body: cw.buf.Bytes()})
+				}
+				if err != nil {
+					slog.ErrorContext(r.Context(), "idempotency save failed", slog.Any("err", err))
+				}
+				if p != nil {
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// replayHeaders describe the stored response itself. Everything else, such
+// as RateLimit-* or X-Trace-Id, belongs to the request being answered and
+// is set afresh by the middleware in front of this one.
+var replayHeaders = []string{"Content-Type", "Content-Language", "Content-Location", "Location", "Link", "ETag", "Last-Modified", "Cache-Control"}
+
+// replayable returns the replayHeaders in h.
+func replayable(h http.Header) http.Header {
+	out := http.Header{}
+	for _, k := range replayHeaders {
+		if vs := h.Values(k); len(vs) > 0 {
+			out[k] = slices.Clone(vs)
+		}
+	}
+	return out
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, resp *StoredResponse) {
+	// Responses stored before replayHeaders existed may carry more.
+	for k, vs := range replayable(resp.Header) {
+		w.Header()[k] = vs
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+// captureWriter copies the status and body while passing them through.
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buf         bytes.Buffer
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	if !c.wroteHeader {
+		c.status, c.wroteHeader = status, true
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	c.wroteHeader = true
+	c.buf.Write(p)
+	return c.ResponseWriter.Write(p)
+}
+
+// MemoryIdempotency keeps keys in process memory.
+type MemoryIdempotency struct {
+	mu      sync.Mutex
+	records map[string]*memRecord
+	sweep   time.Time
+}
+
+type memRecord struct {
+	IdempotencyRecord
+	exp time.Time
+}
+
+func NewMemoryIdempotency() *MemoryIdempotency {
+	return &MemoryIdempotency{records: map[string]*memRecord{}, sweep: time.Now()}
+}
+
+func (m *MemoryIdempotency) Begin(_ context.Context, key, fp string, ttl time.Duration) (*IdempotencyRecord, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.Sub(m.sweep) > time.Minute {
+		m.sweep = now
+		for k, rec := range m.records {
+			if now.After(rec.exp) {
+				delete(m.records, k)
+			}
+		}
+	}
+	if rec, ok := m.records[key]; ok && now.Before(rec.exp) {
+		cp := rec.IdempotencyRecord
+		return &cp, nil
+	}
+	m.records[key] = &memRecord{IdempotencyRecord: IdempotencyRecord{Fingerprint: fp}, exp: now.Add(ttl)}
+	return nil, nil
+}
+
+func (m *MemoryIdempotency) Complete(_ context.Context, key string, resp StoredResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.records[key]
+	if !ok {
+		return errors.New("idempotency key expired before completion")
+	}
+	rec.Response = &resp
+	return nil
+}
+
+func (m *MemoryIdempotency) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.records, key)
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,77 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostgresIdempotency keeps keys in the idempotency_keys table so a retry
+// that lands on another instance still replays.
+type PostgresIdempotency struct{ DB *gorm.DB }
+
+func NewPostgresIdempotency(db *gorm.DB) *PostgresIdempotency {
+	return &PostgresIdempotency{DB: db}
+}
+
+type idempotencyRow struct {
+	Key         string
+	Fingerprint string
+	Status      *int
+	Headers     []byte
+	Body        []byte
+}
+
+func (s *PostgresIdempotency) Begin(ctx context.Context, key, fp string, ttl time.Duration) (*IdempotencyRecord, error) {
+	db := s.DB.WithContext(ctx)
+	if err := db.Exec("DELETE FROM idempotency_keys WHERE key = ? AND expires_at < now()", key).Error; err != nil {
+		return nil, err
+	}
+	res := db.Exec("INSERT INTO idempotency_keys (key, fingerprint, expires_at) VALUES (?, ?, ?) ON CONFLICT (key) DO NOTHING", key, fp, time.Now().Add(ttl))
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil
+	}
+	var row idempotencyRow
+	if err := db.Raw("SELECT key, fingerprint, status, headers, body FROM idempotency_keys WHERE key = ?", key).Scan(&row).Error; err != nil {
+		return nil, err
+	}
+	if row.Key == "" {
+		return nil, errors.New("idempotency key vanished during reservation")
+	}
+	rec := &IdempotencyRecord{Fingerprint: row.Fingerprint}
+	if row.Status != nil {
+		resp := &StoredResponse{Status: *row.Status, Body: row.Body}
+		if err := json.Unmarshal(row.Headers, &resp.Header); err != nil {
+			return nil, err
+		}
+		rec.Response = resp
+	}
+	return rec, nil
+}
+
+func (s *PostgresIdempotency) Complete(ctx context.Context, key string, resp StoredResponse) error {
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	headers, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+	return s.DB.WithContext(ctx).Exec("UPDATE idempotency_keys SET status = ?, headers = ?, body = ? WHERE key = ?", resp.Status, headers, resp.Body, key).Error
+}
+
+func (s *PostgresIdempotency) Release(ctx context.Context, key string) error {
+	return s.DB.WithContext(ctx).Exec("DELETE FROM idempotency_keys WHERE key = ?", key).Error
+}
+
+// Prune deletes expired keys.
+func (s *PostgresIdempotency) Prune(ctx context.Context) error {
+	return s.DB.WithContext(ctx).Exec("DELETE FROM idempotency_keys WHERE expires_at < now()").Error
+}
@@ -0,0 +1,92 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// idempotent wraps h and returns a function sending a POST with key to it.
+func idempotent(t *testing.T, h http.HandlerFunc) func(target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	srv := Idempotency(NewMemoryIdempotency(), time.Hour)(h)
+	return func(target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		r.Header.Set("Idempotency-Key", "k1")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		return w
+	}
+}
+
+func TestIdempotencyReplaysContentHeadersOnly(t *testing.T) {
+	calls := 0
+	post := idempotent(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/v1/watchlists/1")
+		w.Header().Set("RateLimit-Remaining", "9")
+		w.Header().Set("X-Trace-Id", "abc")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	})
+	post("/v1/watchlists", `{}`)
+	w := post("/v1/watchlists", `{}`)
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if w.Code != http.StatusCreated || w.Body.String() != `{"id":"1"}` || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay = %d %q %v", w.Code, w.Body, w.Header())
+	}
+	if w.Header().Get("Location") != "/v1/watchlists/1" || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("content headers not replayed: %v", w.Header())
+	}
+	if w.Header().Get("RateLimit-Remaining") != "" || w.Header().Get("X-Trace-Id") != "" {
+		t.Fatalf("per-request headers replayed: %v", w.Header())
+	}
+}
+
+func TestIdempotencyFingerprintsQuery(t *testing.T) {
+	post := idempotent(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	post("/v1/watchlists/1/items?position=0", `{}`)
+	if w := post("/v1/watchlists/1/items?position=5", `{}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("key reused with another query: got %d, want 422", w.Code)
+	}
+}
+
+func TestIdempotencyReleasesUnansweredKeys(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		fail func(w http.ResponseWriter)
+	}{
+		{"no response", func(http.ResponseWriter) {}},
+		{"server error", func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) }},
+		{"panic", func(http.ResponseWriter) { panic("boom") }},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			calls := 0
+			post := idempotent(t, func(w http.ResponseWriter, r *http.Request) {
+				if calls++; calls == 1 {
+					c.fail(w)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+			})
+			func() {
+				defer func() {
+					if p := recover(); (p != nil) != (c.name == "panic") {
+						t.Fatalf("recovered %v", p)
+					}
+				}()
+				post("/v1/watchlists", `{}`)
+			}()
+			if w := post("/v1/watchlists", `{}`); w.Code != http.StatusCreated || calls != 2 {
+				t.Fatalf("retry got %d after %d calls, want a fresh 201", w.Code, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Timeouts bound how long a single connection may take at each stage.
+type Timeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+// Lifecycle controls how Serve shuts down.
+type Lifecycle struct {
+	// DrainDelay is how long /readyz fails before the listener closes, so
+	// load balancers stop sending new requests first.
+	DrainDelay time.Duration
+	// ShutdownTimeout is how long in-flight requests get to finish.
+	ShutdownTimeout time.Duration
+}
+
+// HTTPServer wraps the router in an http.Server with timeouts t.
+func (s *Server) HTTPServer(addr string, t Timeouts) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           s.Router,
+		ReadHeaderTimeout: t.ReadHeader,
+		ReadTimeout:       t.Read,
+		WriteTimeout:      t.Write,
+		IdleTimeout:       t.Idle,
+	}
+}
+
+// Serve runs hs until ctx is cancelled or the listener fails. On
+// cancellation it marks the server not ready, waits DrainDelay, then shuts
+// down gracefully within ShutdownTimeout.
+func (s *Server) Serve(ctx context.Context, hs *http.Server, lc Lifecycle) error {
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("listening", slog.String("addr", hs.Addr))
+		errCh <- hs.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutdown: failing readiness", slog.Duration("drain_delay", lc.DrainDelay))
+	s.SetReady(false)
+	select {
+	case <-time.After(lc.DrainDelay):
+	case err := <-errCh:
+		return err
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), lc.ShutdownTimeout)
+	defer cancel()
+	if err := hs.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("shutdown: http server stopped")
+	return nil
+}
@@ -0,0 +1,170 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yourname/moodle/internal/auth"
+	"github.com/yourname/moodle/internal/i18n"
+	"github.com/yourname/moodle/internal/problem"
+)
+
+// RatePolicy is a token bucket: Burst tokens, refilled at PerMinute per minute.
+type RatePolicy struct {
+	Name      string
+	PerMinute int
+	Burst     int
+}
+
+func (p RatePolicy) rate() float64 { return float64(p.PerMinute) / 60 }
+
+// refill is how long an empty bucket takes to fill up again.
+func (p RatePolicy) refill() time.Duration {
+	if p.rate() <= 0 {
+		return 0
+	}
+	return time.Duration(float64(p.Burst) / p.rate() * float64(time.Second))
+}
+
+// idleEviction is the least time a bucket is kept after its last use, so
+// generous policies do not churn their buckets.
+const idleEviction = 10 * time.Minute
+
+// RateDecision is the outcome of taking one token.
+type RateDecision struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until a token is available; zero when allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// RateLimitBackend stores buckets. MemoryRateLimiter suits a single
+// instance; PostgresRateLimiter shares buckets across instances.
+type RateLimitBackend interface {
+	Take(ctx context.Context, key string, p RatePolicy) (RateDecision, error)
+}
+
+// decide turns a refilled token count into a decision, spending one token
+// when available. It returns the tokens left in the bucket.
+func decide(tokens float64, p RatePolicy) (RateDecision, float64) {
+	d := RateDecision{}
+	if tokens >= 1 {
+		tokens--
+		d.Allowed = true
+	} else if p.rate() > 0 {
+		d.RetryAfter = time.Duration((1 - tokens) / p.rate() * float64(time.Second))
+	}
+	d.Remaining = int(math.Floor(tokens))
+	if p.rate() > 0 {
+		d.Reset = time.Duration((float64(p.Burst) - tokens) / p.rate() * float64(time.Second))
+	}
+	return d, tokens
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// idle is how long the bucket is kept unused: until it would be full.
+	idle time.Duration
+}
+
+// MemoryRateLimiter keeps buckets in process memory.
+type MemoryRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweep   time.Time
+}
+
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{buckets: map[string]*bucket{}, sweep: time.Now()}
+}
+
+func (m *MemoryRateLimiter) Take(_ context.Context, key string, p RatePolicy) (RateDecision, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evictIdle(now)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(p.Burst), last: now}
+		m.buckets[key] = b
+	}
+	b.idle = max(p.refill(), idleEviction)
+	b.tokens = math.Min(float64(p.Burst), b.tokens+now.Sub(b.last).Seconds()*p.rate())
+	b.last = now
+	d, left := decide(b.tokens, p)
+	b.tokens = left
+	return d, nil
+}
+
+// evictIdle drops buckets that have refilled since their last use, at most
+// once a minute; a new bucket starts full, so nothing is lost.
+func (m *MemoryRateLimiter) evictIdle(now time.Time) {
+	if now.Sub(m.sweep) < time.Minute {
+		return
+	}
+	m.sweep = now
+	for k, b := range m.buckets {
+		if now.Sub(b.last) > b.idle {
+			delete(m.buckets, k)
+		}
+	}
+}
+
+// RateLimit limits requests per caller under policy p. Callers are keyed by
+// auth.UserID when the request is authenticated and by client IP otherwise,
+// so mount it after the verifier on authed groups and after
+// middleware.RealIP everywhere. Backend errors let the request through.
+func RateLimit(b RateLimitBackend, p RatePolicy) func(http.Handler) http.Handler {
+	policyHeader := fmt.Sprintf("%d;w=60;burst=%d", p.PerMinute, p.Burst)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if p.PerMinute <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := p.Name + ":" + rateKey(r)
+			d, err := b.Take(r.Context(), key, p)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "rate limiter unavailable", slog.String("policy", p.Name), slog.Any("err", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			h := w.Header()
+			h.Set("RateLimit-Policy", policyHeader)
+			h.Set("RateLimit-Limit", strconv.Itoa(p.Burst))
+			h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+			if !d.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(d.RetryAfter)))
+				problem.Write(w, r, problem.Problem{Type: problem.TypeRateLimited, Status: http.StatusTooManyRequests, Detail: i18n.T(r.Context(), "rate limit exceeded for %s", p.Name)})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rateKey(r *http.Request) string {
+	if uid := auth.UserID(r.Context()); uid != "" {
+		return "user:" + uid
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,51 @@
+package httpserver
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// PostgresRateLimiter keeps buckets in the rate_limits table so every
+// instance behind the load balancer shares them. Refill and spend happen in
+// one upsert, so concurrent requests cannot overspend a bucket.
+type PostgresRateLimiter struct{ DB *gorm.DB }
+
+func NewPostgresRateLimiter(db *gorm.DB) *PostgresRateLimiter {
+	return &PostgresRateLimiter{DB: db}
+}
+
+const takeSQL = `
+INSERT INTO rate_limits AS rl (key, tokens, allowed, updated_at, refill)
+VALUES (@key, @burst - 1, TRUE, now(), make_interval(secs => @refill))
+ON CONFLICT (key) DO UPDATE SET
+    allowed = LEAST(@burst, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * @rate) >= 1,
+    tokens = LEAST(@burst, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * @rate)
+        - CASE WHEN LEAST(@burst, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * @rate) >= 1 THEN 1 ELSE 0 END,
+    updated_at = now(),
+    refill = make_interval(secs => @refill)
+RETURNING tokens, allowed`
+
+func (s *PostgresRateLimiter) Take(ctx context.Context, key string, p RatePolicy) (RateDecision, error) {
+	var row struct {
+		Tokens  float64
+		Allowed bool
+	}
+	args := map[string]any{"key": key, "burst": float64(p.Burst), "rate": p.rate(), "refill": max(p.refill(), idleEviction).Seconds()}
+	if err := s.DB.WithContext(ctx).Raw(takeSQL, args).Scan(&row).Error; err != nil {
+		return RateDecision{}, err
+	}
+	// decide spends a token on allowed requests, so hand it the pre-spend count.
+	tokens := row.Tokens
+	if row.Allowed {
+		tokens++
+	}
+	d, _ := decide(tokens, p)
+	return d, nil
+}
+
+// Prune deletes buckets idle for longer than their policy takes to refill,
+// which come back full when next used anyway.
+func (s *PostgresRateLimiter) Prune(ctx context.Context) error {
+	return s.DB.WithContext(ctx).Exec("DELETE FROM rate_limits WHERE updated_at + refill < now()").Error
+}
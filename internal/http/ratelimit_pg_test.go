@@ -0,0 +1,47 @@
+package httpserver
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/yourname/moodle/internal/store/pgtest"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+func TestPostgresRateLimiter(t *testing.T) {
+	db := pgtest.DB(t)
+	s := NewPostgresRateLimiter(db)
+	ctx := context.Background()
+	fast := RatePolicy{Name: "api", PerMinute: 60, Burst: 2} // refills in 2s
+	slow := RatePolicy{Name: "ai", PerMinute: 1, Burst: 60}  // refills in an hour
+
+	for i, want := range []bool{true, true, false} {
+		d, err := s.Take(ctx, "pgtest:fast", fast)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Allowed != want || d.Remaining != max(1-i, 0) {
+			t.Fatalf("take %d = %+v, want allowed %v", i+1, d, want)
+		}
+	}
+	if _, err := s.Take(ctx, "pgtest:slow", slow); err != nil {
+		t.Fatal(err)
+	}
+
+	// Twenty idle minutes refill the fast bucket but not the slow one.
+	if err := db.Exec("UPDATE rate_limits SET updated_at = updated_at - interval '20 minutes' WHERE key LIKE 'pgtest:%'").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	if err := db.Raw("SELECT key FROM rate_limits WHERE key LIKE 'pgtest:%'").Scan(&left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0] != "pgtest:slow" {
+		t.Fatalf("buckets after Prune = %v, want only pgtest:slow", left)
+	}
+}
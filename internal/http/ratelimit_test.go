@@ -0,0 +1,115 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yourname/moodle/internal/auth"
+)
+
+// limited serves 204s behind RateLimit with p and returns a function
+// sending a request from addr, as uid when set.
+func limited(m *MemoryRateLimiter, p RatePolicy) func(addr, uid string) *httptest.ResponseRecorder {
+	h := RateLimit(m, p)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }))
+	return func(addr, uid string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/movies/550", nil)
+		r.RemoteAddr = addr
+		if uid != "" {
+			r = r.WithContext(auth.WithUserID(r.Context(), uid))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	get := limited(NewMemoryRateLimiter(), RatePolicy{Name: "api", PerMinute: 60, Burst: 2})
+	for i, remaining := range []string{"1", "0"} {
+		w := get("10.0.0.1:5000", "")
+		if w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Remaining") != remaining {
+			t.Fatalf("request %d: %d, remaining %q", i+1, w.Code, w.Header().Get("RateLimit-Remaining"))
+		}
+		if w.Header().Get("RateLimit-Policy") != "60;w=60;burst=2" || w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("Retry-After") != "" {
+			t.Fatalf("request %d headers = %v", i+1, w.Header())
+		}
+	}
+	w := get("10.0.0.1:5000", "")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" || w.Header().Get("RateLimit-Reset") != "2" {
+		t.Fatalf("over the limit: %d, headers %v", w.Code, w.Header())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("Content-Type = %q, want problem+json", ct)
+	}
+}
+
+func TestRateLimitRefills(t *testing.T) {
+	m := NewMemoryRateLimiter()
+	p := RatePolicy{Name: "api", PerMinute: 60, Burst: 1}
+	ctx := context.Background()
+	if d, _ := m.Take(ctx, "k", p); !d.Allowed {
+		t.Fatal("first take refused")
+	}
+	if d, _ := m.Take(ctx, "k", p); d.Allowed || d.RetryAfter <= 0 || d.RetryAfter > time.Second {
+		t.Fatalf("second take = %+v, want refused for up to a second", d)
+	}
+	m.buckets["k"].last = m.buckets["k"].last.Add(-time.Second)
+	if d, _ := m.Take(ctx, "k", p); !d.Allowed {
+		t.Fatalf("take after a second = %+v, want a refilled token", d)
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	m := NewMemoryRateLimiter()
+	get := limited(m, RatePolicy{Name: "api", PerMinute: 60, Burst: 1})
+	if w := get("10.0.0.1:5000", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("first anonymous request = %d", w.Code)
+	}
+	if w := get("10.0.0.1:6000", ""); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("same IP, other port = %d, want 429", w.Code)
+	}
+	if w := get("10.0.0.2:5000", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("other IP = %d, want its own bucket", w.Code)
+	}
+	// A signed-in user has one bucket wherever they connect from.
+	if w := get("10.0.0.1:5000", "u1"); w.Code != http.StatusNoContent {
+		t.Fatalf("user on a limited IP = %d, want its own bucket", w.Code)
+	}
+	if w := get("10.0.0.3:5000", "u1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("same user, other IP = %d, want 429", w.Code)
+	}
+	for _, k := range []string{"api:ip:10.0.0.1", "api:ip:10.0.0.2", "api:user:u1"} {
+		if _, ok := m.buckets[k]; !ok {
+			t.Errorf("no bucket %q in %v", k, m.buckets)
+		}
+	}
+}
+
+func TestRateLimitEviction(t *testing.T) {
+	m := NewMemoryRateLimiter()
+	ctx := context.Background()
+	fast := RatePolicy{Name: "api", PerMinute: 60, Burst: 10} // refills in 10s
+	slow := RatePolicy{Name: "ai", PerMinute: 1, Burst: 60}   // refills in an hour
+	for range 60 {
+		_, _ = m.Take(ctx, "fast", fast)
+		_, _ = m.Take(ctx, "slow", slow)
+	}
+	idle := time.Now().Add(-20 * time.Minute)
+	m.buckets["fast"].last, m.buckets["slow"].last = idle, idle
+	m.sweep = time.Now().Add(-2 * time.Minute)
+
+	m.evictIdle(time.Now())
+	if _, ok := m.buckets["fast"]; ok {
+		t.Error("refilled bucket was kept")
+	}
+	b, ok := m.buckets["slow"]
+	if !ok {
+		t.Fatal("bucket evicted before it refilled, handing the caller a full one")
+	}
+	if d, _ := m.Take(ctx, "slow", slow); d.Remaining != 19 {
+		t.Fatalf("slow bucket after 20 minutes = %+v (tokens %.1f), want 19 left", d, b.tokens)
+	}
+}
@@ -3,15 +3,26 @@ package httpserver
 import (
 	"encoding/json"
 	"net/http"
+	"sync/atomic"
 	time "time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+
+	"github.com/yourname/moodle/internal/health"
+	"github.com/yourname/moodle/internal/i18n"
+	"github.com/yourname/moodle/internal/logging"
+	"github.com/yourname/moodle/internal/metrics"
+	"github.com/yourname/moodle/internal/problem"
+	"github.com/yourname/moodle/internal/tracing"
 )
 
 type Server struct {
 	Router *chi.Mux
+	// ready is cleared when shutdown begins so /readyz fails before draining.
+	ready  atomic.Bool
+	checks *health.Registry
 }
 
 // NewServer builds the base router and allows callers to mount versioned routes.
@@ -19,20 +30,30 @@ func NewServer(mounters ...func(r chi.Router)) *Server {
 	r := chi.NewRouter()
 	// Core middlewares
 	r.Use(middleware.RequestID)
+	r.Use(tracing.Middleware)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(i18n.Middleware)
+	r.Use(logging.RequestLogger)
+	r.Use(metrics.Middleware)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Accept-Language", "Authorization", "Content-Type", "X-CSRF-Token", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Link", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "Idempotent-Replayed", "X-Trace-Id"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		problem.NotFound(w, r, i18n.T(r.Context(), "no route for %s", r.URL.Path))
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, problem.Problem{Type: problem.TypeMethodNotAllowed, Status: http.StatusMethodNotAllowed, Detail: i18n.T(r.Context(), "%s is not allowed on %s", r.Method, r.URL.Path)})
+	})
+
 	// basic health
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -40,6 +61,26 @@ func NewServer(mounters ...func(r chi.Router)) *Server {
 		_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 	})
 
+	s := &Server{Router: r}
+	s.ready.Store(true)
+	// livez only says the process is serving; restart it if this fails.
+	r.Get("/livez", func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, health.Report{Status: health.StatusPass})
+	})
+	// readyz says whether to route traffic here: dependencies are healthy
+	// and shutdown has not begun.
+	r.Get("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !s.ready.Load() {
+			writeHealth(w, health.Report{Status: health.StatusFail, Output: "shutting down"})
+			return
+		}
+		if s.checks == nil {
+			writeHealth(w, health.Report{Status: health.StatusPass})
+			return
+		}
+		writeHealth(w, s.checks.Run(r.Context()))
+	})
+
 	// Versioned API
 	r.Route("/v1", func(v1 chi.Router) {
 		for _, m := range mounters {
@@ -49,5 +90,20 @@ func NewServer(mounters ...func(r chi.Router)) *Server {
 		}
 	})
 
-	return &Server{Router: r}
+	return s
+}
+
+// SetReady flips the /readyz answer.
+func (s *Server) SetReady(ready bool) { s.ready.Store(ready) }
+
+// UseChecks makes /readyz run reg. Call it before serving.
+func (s *Server) UseChecks(reg *health.Registry) { s.checks = reg }
+
+func writeHealth(w http.ResponseWriter, rep health.Report) {
+	w.Header().Set("Content-Type", health.ContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	if rep.Status == health.StatusFail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(rep)
 }
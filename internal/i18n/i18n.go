@@ -0,0 +1,144 @@
+// Package i18n picks a locale for each request from its Accept-Language
+// header and translates API messages into it. Catalogs are keyed by the
+// English message (a fmt format for messages with arguments), so anything
+// without a translation is served in English.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed catalogs/*.json
+var bundled embed.FS
+
+// supported are the languages with a catalog; English is the source
+// language and the fallback.
+var supported = []language.Tag{language.English, language.Spanish, language.French, language.German, language.Portuguese}
+
+var (
+	matcher  = language.NewMatcher(supported)
+	catalogs = loadCatalogs()
+)
+
+// Locale is the language and region a request asked for.
+type Locale struct {
+	// Language is the BCP 47 tag passed to TMDb, e.g. "fr-FR". It is empty
+	// for English (en, en-US), which is TMDb's default.
+	Language string
+	// Region is the ISO 3166-1 country of the preferred tag, e.g. "FR", or
+	// empty when it named none.
+	Region string
+	// catalog is the supported language messages are translated into.
+	catalog string
+}
+
+// Parse picks a Locale from an Accept-Language header. TMDb gets the
+// caller's first choice whatever it is; messages use the best supported
+// match. A missing or malformed header gives the zero Locale: English.
+func Parse(acceptLanguage string) Locale {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Locale{}
+	}
+	var loc Locale
+	// A wildcard (*) parses as "mul" and leaves the choice to us.
+	if base, conf := tags[0].Base(); conf == language.Exact && base.String() != "mul" {
+		loc.Language = base.String()
+		// TMDb takes countries only, not areas such as 419 (Latin America).
+		if region, conf := tags[0].Region(); conf == language.Exact && region.IsCountry() {
+			loc.Region = region.String()
+			loc.Language += "-" + loc.Region
+		}
+	}
+	if loc.Language == "en" || loc.Language == "en-US" {
+		loc.Language = ""
+	}
+	if _, i, conf := matcher.Match(tags...); conf != language.No {
+		base, _ := supported[i].Base()
+		loc.catalog = base.String()
+	}
+	return loc
+}
+
+// Text returns msg in l's language, or msg itself when it has no
+// translation.
+func (l Locale) Text(msg string) string {
+	if t, ok := catalogs[l.catalog][msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// Sprintf formats args with the translation of format.
+func (l Locale) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(l.Text(format), args...)
+}
+
+type ctxKey struct{}
+
+// NewContext returns ctx carrying l.
+func NewContext(ctx context.Context, l Locale) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the Locale in ctx, or the zero (English) Locale.
+func FromContext(ctx context.Context) Locale {
+	l, _ := ctx.Value(ctxKey{}).(Locale)
+	return l
+}
+
+// T formats args with the translation of format into ctx's language.
+func T(ctx context.Context, format string, args ...any) string {
+	return FromContext(ctx).Sprintf(format, args...)
+}
+
+// Middleware stores the Locale from Accept-Language in the request
+// context. Responses vary by it, so shared caches keep them apart.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		loc := Parse(r.Header.Get("Accept-Language"))
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), loc)))
+	})
+}
+
+var verb = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+// loadCatalogs reads catalogs/<lang>.json, each an object from English
+// message to translation. A translation must use the same verbs in the
+// same order as its message.
+func loadCatalogs() map[string]map[string]string {
+	names, err := fs.Glob(bundled, "catalogs/*.json")
+	if err != nil {
+		panic(err)
+	}
+	out := make(map[string]map[string]string, len(names))
+	for _, name := range names {
+		b, err := bundled.ReadFile(name)
+		if err != nil {
+			panic(err)
+		}
+		var c map[string]string
+		if err := json.Unmarshal(b, &c); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", name, err))
+		}
+		for msg, t := range c {
+			if !slices.Equal(verb.FindAllString(msg, -1), verb.FindAllString(t, -1)) {
+				panic(fmt.Sprintf("i18n: %s: %q does not take the arguments of %q", name, t, msg))
+			}
+		}
+		out[strings.TrimSuffix(path.Base(name), ".json")] = c
+	}
+	return out
+}
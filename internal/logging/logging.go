@@ -0,0 +1,123 @@
+// Package logging configures log/slog for the API: JSON (or text) output,
+// a level from the environment, per-request fields taken from the context
+// and automatic redaction of credentials.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config mirrors the LOG_* settings.
+type Config struct {
+	Level  string // debug, info, warn or error
+	Format string // json or text
+}
+
+// ParseLevel maps a LOG_LEVEL value to a slog level.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+	return l, nil
+}
+
+// New builds a logger writing to w. Every record logged with a request
+// context carries request_id, user_id, route and trace_id when known.
+func New(w io.Writer, c Config) (*slog.Logger, error) {
+	level, err := ParseLevel(c.Level)
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var h slog.Handler
+	switch c.Format {
+	case "", "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", c.Format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// Setup builds a logger and makes it the slog and log package default.
+func Setup(w io.Writer, c Config) error {
+	l, err := New(w, c)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(l)
+	return nil
+}
+
+type fieldsKey struct{}
+
+// fields holds values learned after the request context was created, so
+// that lines logged further out (like the access log) still see them.
+type fields struct {
+	userID string
+}
+
+// WithFields prepares ctx to carry fields set later with SetUserID.
+func WithFields(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, fieldsKey{}, &fields{})
+}
+
+// SetUserID records the authenticated user on the request's log fields.
+func SetUserID(ctx context.Context, id string) {
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		f.userID = id
+	}
+}
+
+// contextHandler adds request-scoped attributes to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if id := middleware.GetReqID(ctx); id != "" {
+		rec.AddAttrs(slog.String("request_id", id))
+	}
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok && f.userID != "" {
+		rec.AddAttrs(slog.String("user_id", f.userID))
+	}
+	if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+		rec.AddAttrs(slog.String("route", rctx.RoutePattern()))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		rec.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Printer adapts the default logger to libraries that take a Printf-style
+// writer, such as GORM's logger.
+type Printer struct {
+	Level slog.Level
+}
+
+func (p Printer) Printf(format string, args ...any) {
+	slog.Log(context.Background(), p.Level, strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
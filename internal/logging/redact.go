@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// secretKeys are attribute names whose values are never logged.
+var secretKeys = map[string]bool{
+	"token": true, "access_token": true, "refresh_token": true, "id_token": true,
+	"authorization": true, "cookie": true, "set-cookie": true,
+	"api_key": true, "apikey": true, "password": true, "secret": true,
+}
+
+// secretParam matches credential query parameters wherever they appear:
+// in URLs, bare query strings, URLs quoted inside error messages, or
+// percent-encoded inside another parameter such as redirect_to.
+var secretParam = regexp.MustCompile(`(^|[^A-Za-z0-9_]|%3[fF]|%26)(api_key|key|token|access_token|refresh_token|id_token|code)(=|%3[dD])[^&\s"'#]+`)
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if secretKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	var s string
+	switch a.Value.Kind() {
+	case slog.KindString:
+		s = a.Value.String()
+	case slog.KindAny:
+		// Errors from HTTP clients quote the request URL, keys and all.
+		err, ok := a.Value.Any().(error)
+		if !ok {
+			return a
+		}
+		s = err.Error()
+		if !hasParam(s) {
+			return a
+		}
+	default:
+		return a
+	}
+	if strings.HasPrefix(strings.ToLower(s), "bearer ") {
+		return slog.String(a.Key, redacted)
+	}
+	if hasParam(s) {
+		return slog.String(a.Key, RedactURL(s))
+	}
+	return a
+}
+
+func hasParam(s string) bool {
+	return strings.Contains(s, "=") || strings.Contains(s, "%3D") || strings.Contains(s, "%3d")
+}
+
+// RedactURL replaces the values of credential query parameters in s with a
+// placeholder.
+func RedactURL(s string) string {
+	return secretParam.ReplaceAllString(s, "$1$2$3"+redacted)
+}
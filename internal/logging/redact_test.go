@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var secret = regexp.MustCompile(`s3cret\d+`)
+
+func TestRedaction(t *testing.T) {
+	tmdbErr := &url.Error{Op: "Get", URL: "https://api.themoviedb.org/3/movie/550?api_key=s3cret3&language=fr-FR", Err: errors.New("context deadline exceeded")}
+	cases := []struct {
+		name  string
+		attrs []any
+		keep  []string
+	}{
+		{"oauth url", []any{slog.String("oauth_url", "https://x.supabase.co/auth/v1/authorize?provider=google&redirect_to=https%3A%2F%2Fapp%2Fcb#access_token=s3cret1&token_type=bearer")}, []string{"provider=google"}},
+		{"callback query", []any{slog.String("query", "code=s3cret2&state=abc")}, []string{"state=abc"}},
+		{"url error", []any{slog.Any("err", tmdbErr)}, []string{"language=fr-FR", "deadline exceeded"}},
+		{"wrapped url error", []any{slog.Any("err", fmt.Errorf("tmdb: get movie: %w", tmdbErr))}, []string{"tmdb: get movie"}},
+		{"refresh token", []any{slog.String("body", "grant_type=refresh_token&refresh_token=s3cret4")}, []string{"grant_type=refresh_token"}},
+		{"authorization", []any{slog.String("Authorization", "Bearer s3cret5")}, []string{`"Authorization":"[REDACTED]"`}},
+		{"bearer anywhere", []any{slog.String("header", "bearer s3cret6")}, nil},
+		{"cookie", []any{slog.String("Cookie", "sb-access-token=s3cret7; theme=dark")}, []string{`"Cookie":"[REDACTED]"`}},
+		{"nested groups", []any{slog.Group("req", slog.Group("headers", slog.String("authorization", "Bearer s3cret8")), slog.String("url", "/auth/callback?code=s3cret9"))}, []string{"/auth/callback?code=[REDACTED]"}},
+		{"encoded redirect", []any{slog.String("query", "redirect_to=https%3A%2F%2Fapp%2Fcb%3Fstate%3Dx%26access_token%3Ds3cret13&provider=google")}, []string{"provider=google"}},
+		{"api key attr", []any{slog.String("api_key", "s3cret10")}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := New(&buf, Config{Level: "debug", Format: "json"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.DebugContext(context.Background(), "test", c.attrs...)
+			out := buf.String()
+			if s := secret.FindString(out); s != "" {
+				t.Fatalf("%s reached the log:\n%s", s, out)
+			}
+			for _, k := range c.keep {
+				if !strings.Contains(out, k) {
+					t.Errorf("log lost %q:\n%s", k, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactionWithAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, Config{Level: "info", Format: "text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.With("token", "s3cret11").WithGroup("tmdb").Info("call", "url", "https://api.themoviedb.org/3/configuration?api_key=s3cret12")
+	if s := secret.FindString(buf.String()); s != "" {
+		t.Fatalf("%s reached the log:\n%s", s, buf.String())
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	cases := map[string]string{
+		"/3/movie/550?api_key=abc&language=de":      "/3/movie/550?api_key=[REDACTED]&language=de",
+		"code=abc&state=xyz":                        "code=[REDACTED]&state=xyz",
+		"/cb?monkey=1&barcode=2":                    "/cb?monkey=1&barcode=2",
+		"redirect_to=%2Fcb%3Ftoken%3Dabc&x=1":       "redirect_to=%2Fcb%3Ftoken%3D[REDACTED]&x=1",
+		`Get "https://h/x?key=abc": EOF`:            `Get "https://h/x?key=[REDACTED]": EOF`,
+		"https://h/#access_token=abc&expires_in=60": "https://h/#access_token=[REDACTED]&expires_in=60",
+	}
+	for in, want := range cases {
+		if got := RedactURL(in); got != want {
+			t.Errorf("RedactURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Package metrics exposes Prometheus metrics for the API: incoming HTTP
+// requests, outbound TMDb/Gemini calls, caches, the DB pool and a few
+// business counters.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "moodle"
+
+// Registry holds every metric served at /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "http_requests_total",
+		Help: "HTTP requests by method, chi route pattern and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and chi route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	upstreamRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "upstream_requests_total",
+		Help: "Outbound calls by service, operation and status.",
+	}, []string{"service", "operation", "status"})
+	upstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "upstream_request_duration_seconds",
+		Help:    "Outbound call latency by service and operation.",
+		Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 15},
+	}, []string{"service", "operation"})
+	upstreamErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "upstream_errors_total",
+		Help: "Outbound calls that failed without a response (timeouts, DNS, resets).",
+	}, []string{"service", "operation"})
+
+	// WatchlistsCreated counts successful watchlist creations.
+	WatchlistsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Name: "watchlists_created_total",
+		Help: "Watchlists created.",
+	})
+	// Likes counts successful likes.
+	Likes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Name: "likes_total",
+		Help: "Watchlist likes.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		upstreamRequests, upstreamDuration, upstreamErrors,
+		WatchlistsCreated, Likes,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records request counts and latency labelled by the chi route
+// pattern, so /v1/watchlists/{id} is one series rather than one per ID.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// RegisterDB exports connection pool stats for db.
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// CacheStats is implemented by cache.TTLCache.
+type CacheStats interface {
+	Stats() (hits, misses uint64)
+}
+
+// RegisterCache exports hit and miss counts for c under the cache label name.
+func RegisterCache(name string, c CacheStats) {
+	labels := prometheus.Labels{"cache": name}
+	Registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace, Name: "cache_hits_total", Help: "Cache hits.", ConstLabels: labels,
+		}, func() float64 { h, _ := c.Stats(); return float64(h) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace, Name: "cache_misses_total", Help: "Cache misses.", ConstLabels: labels,
+		}, func() float64 { _, m := c.Stats(); return float64(m) }),
+	)
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// operation returns path with numeric segments replaced by :id. The first
+// segment is left alone: it is the API version (TMDb's /3), not an ID.
+func operation(path string) string {
+	segs := strings.Split(path, "/")
+	for i := 2; i < len(segs); i++ {
+		if segs[i] != "" && strings.Trim(segs[i], "0123456789") == "" {
+			segs[i] = ":id"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// Transport instruments outbound requests to one service. The operation
+// label is the request path with numeric IDs replaced by :id.
+type Transport struct {
+	Service string
+	Base    http.RoundTripper
+}
+
+// InstrumentClient wraps c's transport so its calls are recorded under service.
+func InstrumentClient(c *http.Client, service string) {
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c.Transport = &Transport{Service: service, Base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	op := operation(req.URL.Path)
+	start := time.Now()
+	res, err := t.Base.RoundTrip(req)
+	upstreamDuration.WithLabelValues(t.Service, op).Observe(time.Since(start).Seconds())
+	if err != nil {
+		upstreamErrors.WithLabelValues(t.Service, op).Inc()
+		return nil, err
+	}
+	upstreamRequests.WithLabelValues(t.Service, op, strconv.Itoa(res.StatusCode)).Inc()
+	return res, nil
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTransportCollapsesIDs(t *testing.T) {
+	upstreamRequests.Reset()
+	upstreamDuration.Reset()
+	upstreamErrors.Reset()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	c := &http.Client{}
+	InstrumentClient(c, "tmdb")
+
+	paths := []string{"/3/movie/550", "/3/movie/603", "/3/movie/13", "/3/tv/1396/season/2", "/3/tv/1399/season/5", "/3/search/movie"}
+	for _, p := range paths {
+		res, err := c.Get(srv.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	// Six requests over three operations must be three series, not six.
+	if n := testutil.CollectAndCount(upstreamRequests); n != 3 {
+		t.Fatalf("upstream_requests_total has %d series, want 3", n)
+	}
+	if n := testutil.CollectAndCount(upstreamDuration); n != 3 {
+		t.Fatalf("upstream_request_duration_seconds has %d series, want 3", n)
+	}
+	for op, want := range map[string]float64{"/3/movie/:id": 3, "/3/tv/:id/season/:id": 2, "/3/search/movie": 1} {
+		if got := testutil.ToFloat64(upstreamRequests.WithLabelValues("tmdb", op, "200")); got != want {
+			t.Errorf("operation %q counted %v, want %v", op, got, want)
+		}
+	}
+}
+
+func TestTransportCountsErrors(t *testing.T) {
+	upstreamErrors.Reset()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+	c := &http.Client{}
+	InstrumentClient(c, "gemini")
+
+	for _, id := range []string{"1", "2"} {
+		if _, err := c.Get(url + "/v1/models/" + id); err == nil {
+			t.Fatal("request to a closed server succeeded")
+		}
+	}
+	if n := testutil.CollectAndCount(upstreamErrors); n != 1 {
+		t.Fatalf("upstream_errors_total has %d series, want 1", n)
+	}
+	if got := testutil.ToFloat64(upstreamErrors.WithLabelValues("gemini", "/v1/models/:id")); got != 2 {
+		t.Fatalf("errors counted %v, want 2", got)
+	}
+}
+
+func TestOperation(t *testing.T) {
+	for path, want := range map[string]string{
+		"/3/movie/550":                     "/3/movie/:id",
+		"/3/tv/1396/season/2":              "/3/tv/:id/season/:id",
+		"/3/list/1/items/2/3":              "/3/list/:id/items/:id/:id",
+		"/3/search/movie":                  "/3/search/movie",
+		"/3/movie/550/":                    "/3/movie/:id/",
+		"/v1beta/models/gemini-2:generate": "/v1beta/models/gemini-2:generate",
+		"/":                                "/",
+	} {
+		if got := operation(path); got != want {
+			t.Errorf("operation(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
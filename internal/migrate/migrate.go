@@ -0,0 +1,38 @@
+// Package migrate runs the embedded goose migrations against Postgres.
+package migrate
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/lock"
+
+	"github.com/yourname/moodle/migrations"
+)
+
+// DefaultTable is goose's version table.
+const DefaultTable = goose.DefaultTablename
+
+// NewProvider returns a goose provider for the embedded migrations that
+// records versions in table. Up and Down hold a Postgres advisory lock for
+// their whole run, so replicas migrating on boot apply each migration once
+// while the others wait.
+func NewProvider(db *sql.DB, table string) (*goose.Provider, error) {
+	if table == "" {
+		table = DefaultTable
+	}
+	store, err := database.NewStore(database.DialectPostgres, table)
+	if err != nil {
+		return nil, err
+	}
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider("", db, migrations.FS,
+		goose.WithStore(store),
+		goose.WithSessionLocker(locker),
+		goose.WithDisableGlobalRegistry(true),
+	)
+}
@@ -1,11 +1,26 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Media types of watchlist items. TMDb numbers movies and TV shows
+// separately, so an item is named by its media type and TMDb ID together.
+const (
+	MediaMovie = "movie"
+	MediaTV    = "tv"
+)
+
+// Moderation statuses for user-written content.
+const (
+	ModerationOK            = "ok"
+	ModerationPendingReview = "pending_review"
+	ModerationRejected      = "rejected"
+)
+
 type User struct {
 	ID        string         `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	CreatedAt time.Time      `json:"created_at"`
@@ -26,7 +41,11 @@ type Watchlist struct {
 	OwnerID     string `gorm:"type:uuid;index" json:"owner_id"`
 	Title       string `gorm:"not null" json:"title"`
 	Description string `json:"description"`
-	IsPublic    bool   `gorm:"default:true" json:"is_public"`
+	// No gorm default: it would turn an explicit false into true on insert.
+	IsPublic bool `json:"is_public"`
+	// ModerationStatus is pending_review while flagged content awaits a
+	// moderator, and rejected if the moderator turned it down.
+	ModerationStatus string `gorm:"default:ok" json:"moderation_status"`
 
 	Items []WatchlistItem `json:"items"`
 }
@@ -38,12 +57,31 @@ type WatchlistItem struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	WatchlistID string `gorm:"type:uuid;index" json:"watchlist_id"`
+	MediaType   string `gorm:"default:movie" json:"media_type"`
 	TMDBID      int64  `gorm:"index" json:"tmdb_id"`
+	// Title and ReleaseDate hold a show's name and first air date.
 	Title       string `json:"title"`
 	PosterPath  string `json:"poster_path"`
 	ReleaseDate string `json:"release_date"`
 	Notes       string `json:"notes"`
 	Position    int    `gorm:"default:0" json:"position"`
+
+	ModerationStatus string `gorm:"default:ok" json:"moderation_status"`
+}
+
+// Movie is TMDb's details for a movie as last fetched, keyed by TMDb ID.
+// Watchlist items take their title, poster and release date from here when
+// a row exists; Details is the full payload served by GET /v1/movies/{id}.
+type Movie struct {
+	TMDBID    int64     `gorm:"primaryKey;autoIncrement:false" json:"tmdb_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Title       string          `json:"title"`
+	PosterPath  string          `json:"poster_path"`
+	ReleaseDate string          `json:"release_date"`
+	Details     json.RawMessage `gorm:"type:jsonb;not null" json:"details"`
+	FetchedAt   time.Time       `gorm:"index" json:"fetched_at"`
 }
 
 type Like struct {
@@ -61,3 +99,38 @@ type Share struct {
 	WatchlistID string    `gorm:"type:uuid;index" json:"watchlist_id"`
 	Message     string    `json:"message"`
 }
+
+// Block hides the blocker's lists from the blocked user and stops the
+// blocked user from liking them or sharing lists with the blocker.
+type Block struct {
+	ID        string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	BlockerID string    `gorm:"type:uuid;index" json:"blocker_id"`
+	BlockedID string    `gorm:"type:uuid;index" json:"blocked_id"`
+}
+
+// Mute filters the muted user's lists out of the muter's trending and listing results.
+type Mute struct {
+	ID        string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	MuterID   string    `gorm:"type:uuid;index" json:"muter_id"`
+	MutedID   string    `gorm:"type:uuid;index" json:"muted_id"`
+}
+
+// APITokenPrefix starts every API token so the auth middleware can tell
+// them apart from Supabase JWTs.
+const APITokenPrefix = "mdl_"
+
+// APIToken is a long-lived credential for scripts. Only a SHA-256 of the
+// token is stored; Prefix keeps enough of it to recognise in listings.
+type APIToken struct {
+	ID         string     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UserID     string     `gorm:"type:uuid;index" json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `gorm:"uniqueIndex" json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Policy decides what happens to flagged content.
+type Policy string
+
+const (
+	PolicyReject Policy = "reject"
+	PolicyMask   Policy = "mask"
+	PolicyReview Policy = "review"
+)
+
+// ParsePolicy accepts reject, mask or review; empty means reject.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return PolicyReject, nil
+	case PolicyReject, PolicyMask, PolicyReview:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown moderation policy %q", s)
+	}
+}
+
+// Classifier is an optional model-backed check run after the wordlist.
+type Classifier interface {
+	Classify(ctx context.Context, text string) (flagged bool, reason string, err error)
+}
+
+// Outcome is the result of moderating one piece of text.
+type Outcome struct {
+	// Text is the content to store; masked when the policy is mask.
+	Text    string
+	Flagged bool
+	// Action is the policy applied when Flagged; empty otherwise.
+	Action  Policy
+	Reasons []string
+}
+
+// Pipeline runs the wordlist and then the classifier, if any. A nil
+// Pipeline allows everything.
+type Pipeline struct {
+	Policy     Policy
+	Wordlist   *Wordlist
+	Classifier Classifier
+}
+
+func (p *Pipeline) Check(ctx context.Context, text string) (Outcome, error) {
+	out := Outcome{Text: text}
+	if p == nil || strings.TrimSpace(text) == "" {
+		return out, nil
+	}
+	terms, spans := p.Wordlist.Match(text)
+	for _, t := range terms {
+		out.Reasons = append(out.Reasons, "wordlist: "+t)
+	}
+	classified := false
+	if p.Classifier != nil {
+		flagged, reason, err := p.Classifier.Classify(ctx, text)
+		if err != nil {
+			return out, err
+		}
+		if flagged {
+			classified = true
+			out.Reasons = append(out.Reasons, "classifier: "+reason)
+		}
+	}
+	if len(terms) == 0 && !classified {
+		return out, nil
+	}
+	out.Flagged = true
+	out.Action = p.Policy
+	if out.Action == "" {
+		out.Action = PolicyReject
+	}
+	if out.Action == PolicyMask {
+		out.Text = mask(text, spans)
+		// The classifier gives no span to mask, so hold such content for review.
+		if classified {
+			out.Action = PolicyReview
+		}
+	}
+	return out, nil
+}
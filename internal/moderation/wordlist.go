@@ -0,0 +1,226 @@
+package moderation
+
+import (
+	"bufio"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// leet maps common character substitutions back to the letter they stand for.
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l', '+': 't',
+}
+
+// Wordlist flags words from a fixed list after lowercasing and undoing
+// leetspeak, so "h4te" and "HAAATE" both match "hate". Entries of several
+// words are phrases: they match those words in a row whatever separates
+// them, so "kys now" also catches "KYS... n0w".
+type Wordlist struct {
+	words map[string]struct{}
+	// phrases are keyed by their first word.
+	phrases map[string][][]string
+	n       int
+}
+
+func NewWordlist(words []string) *Wordlist {
+	w := &Wordlist{words: make(map[string]struct{}, len(words)), phrases: map[string][][]string{}}
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		runes := []rune(word)
+		var toks []string
+		for _, sp := range tokens(runes) {
+			toks = append(toks, normalize(string(runes[sp.start:sp.end])))
+		}
+		switch len(toks) {
+		case 0:
+			continue
+		case 1:
+			w.words[toks[0]] = struct{}{}
+		default:
+			w.phrases[toks[0]] = append(w.phrases[toks[0]], toks)
+		}
+		w.n++
+	}
+	return w
+}
+
+// ReadWords reads a wordlist file with one word or phrase per line. Blank lines and
+// # comments are dropped by NewWordlist.
+func ReadWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var words []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		words = append(words, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+func (w *Wordlist) Len() int {
+	if w == nil {
+		return 0
+	}
+	return w.n
+}
+
+// span is a token's [start, end) rune range in the original text.
+type span struct{ start, end int }
+
+// Match returns the listed words and phrases found in text and where they
+// occur. Where a phrase and a word overlap, the longest phrase wins.
+func (w *Wordlist) Match(text string) (terms []string, spans []span) {
+	if w.Len() == 0 {
+		return nil, nil
+	}
+	runes := []rune(text)
+	toks := tokens(runes)
+	for i := 0; i < len(toks); i++ {
+		if phrase := w.phraseAt(runes, toks[i:]); phrase != nil {
+			last := toks[i+len(phrase)-1]
+			terms = append(terms, strings.Join(phrase, " "))
+			spans = append(spans, trimSymbols(runes, toks[i].start, last.end))
+			i += len(phrase) - 1
+			continue
+		}
+		// "h@te!" should match "hate": retry with symbols trimmed off the edges.
+		for _, sp := range []span{toks[i], trimSymbols(runes, toks[i].start, toks[i].end)} {
+			if term, ok := w.lookup(string(runes[sp.start:sp.end])); ok {
+				terms = append(terms, term)
+				spans = append(spans, sp)
+				break
+			}
+		}
+	}
+	return terms, spans
+}
+
+// phraseAt returns the longest phrase whose words are the first tokens of
+// toks, or nil.
+func (w *Wordlist) phraseAt(runes []rune, toks []span) []string {
+	var best []string
+	for _, first := range forms(runes, toks[0]) {
+		for _, p := range w.phrases[first] {
+			if len(p) <= len(best) || len(p) > len(toks) {
+				continue
+			}
+			match := true
+			for j := 1; j < len(p) && match; j++ {
+				match = slices.Contains(forms(runes, toks[j]), p[j])
+			}
+			if match {
+				best = p
+			}
+		}
+	}
+	return best
+}
+
+func (w *Wordlist) lookup(tok string) (string, bool) {
+	tok = normalize(tok)
+	if _, ok := w.words[tok]; ok {
+		return tok, true
+	}
+	tok = squeeze(tok)
+	_, ok := w.words[tok]
+	return tok, ok
+}
+
+// tokens splits runes into runs of word runes.
+func tokens(runes []rune) []span {
+	var out []span
+	start := -1
+	for i, r := range runes {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			out = append(out, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, span{start, len(runes)})
+	}
+	return out
+}
+
+// forms are the normalized spellings a token can match: as written and
+// with symbols trimmed, each also squeezed.
+func forms(runes []rune, sp span) []string {
+	var out []string
+	for _, sp := range []span{sp, trimSymbols(runes, sp.start, sp.end)} {
+		n := normalize(string(runes[sp.start:sp.end]))
+		out = append(out, n, squeeze(n))
+	}
+	return out
+}
+
+func trimSymbols(runes []rune, start, end int) span {
+	for start < end && !unicode.IsLetter(runes[start]) && !unicode.IsDigit(runes[start]) {
+		start++
+	}
+	for end > start && !unicode.IsLetter(runes[end-1]) && !unicode.IsDigit(runes[end-1]) {
+		end--
+	}
+	return span{start, end}
+}
+
+func isWordRune(r rune) bool {
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return true
+	}
+	_, ok := leet[r]
+	return ok
+}
+
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if m, ok := leet[r]; ok {
+			r = m
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// squeeze collapses runs of the same letter ("haaate" -> "hate").
+func squeeze(s string) string {
+	var b strings.Builder
+	var prev rune
+	for i, r := range s {
+		if i > 0 && r == prev {
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+// mask replaces every rune inside spans with '*'.
+func mask(text string, spans []span) string {
+	runes := []rune(text)
+	for _, sp := range spans {
+		for i := sp.start; i < sp.end; i++ {
+			runes[i] = '*'
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,36 @@
+package moderation
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWordlistMatch(t *testing.T) {
+	w := NewWordlist([]string{"# comment", "hate", "$crub", "kill yourself", "kill yourself now"})
+	cases := []struct {
+		text   string
+		terms  []string
+		masked string
+	}{
+		{"I h4te this", []string{"hate"}, "I **** this"},
+		{"HAAATE!", []string{"hate"}, "******!"},
+		{"a $crub list", []string{"scrub"}, "a ***** list"},
+		{"just kill  y0urself", []string{"kill yourself"}, "just **************"},
+		{"KILL-YOURSELF... now", []string{"kill yourself now"}, "********************"},
+		{"kill time, love yourself", nil, "kill time, love yourself"},
+		{"skill yourself", nil, "skill yourself"},
+		{"kill", nil, "kill"},
+	}
+	for _, c := range cases {
+		terms, spans := w.Match(c.text)
+		if !slices.Equal(terms, c.terms) {
+			t.Errorf("Match(%q) terms = %q, want %q", c.text, terms, c.terms)
+		}
+		if got := mask(c.text, spans); got != c.masked {
+			t.Errorf("mask(%q) = %q, want %q", c.text, got, c.masked)
+		}
+	}
+	if n := w.Len(); n != 4 {
+		t.Errorf("Len = %d, want 4", n)
+	}
+}
@@ -0,0 +1,157 @@
+// Package moviecache keeps TMDb movie details in the movies table. Cache
+// stands in front of tmdb.Client.GetMovie, reading through the table, and
+// Refresh refetches entries that have gone stale so watchlist items pick
+// up retitled or re-postered movies without a TMDb call per request.
+package moviecache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yourname/moodle/internal/i18n"
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/store"
+	"github.com/yourname/moodle/internal/tmdb"
+)
+
+// Store is the movies table as the cache uses it.
+type Store interface {
+	Movie(ctx context.Context, tmdbID int64) (*models.Movie, error)
+	SaveMovie(ctx context.Context, m *models.Movie) error
+	StaleMovies(ctx context.Context, cutoff time.Time, limit int) ([]int64, error)
+}
+
+var _ Store = (*store.Store)(nil)
+
+// Defaults for New.
+const (
+	DefaultTTL   = 24 * time.Hour
+	DefaultBatch = 100
+)
+
+// Cache is a tmdb.Client whose GetMovie reads through Store. Every other
+// method goes straight to TMDb.
+type Cache struct {
+	*tmdb.Client
+	Store Store
+	// TTL is how long details are served without asking TMDb again.
+	TTL time.Duration
+	// Batch caps how many stale entries one Refresh refetches.
+	Batch int
+
+	hits, misses atomic.Uint64
+}
+
+func New(client *tmdb.Client, st Store) *Cache {
+	return &Cache{Client: client, Store: st, TTL: DefaultTTL, Batch: DefaultBatch}
+}
+
+// Stats returns the number of GetMovie calls answered from the table and
+// those that went to TMDb.
+func (c *Cache) Stats() (hits, misses uint64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// GetMovie returns the cached details while they are younger than TTL and
+// fetches and stores them otherwise. When TMDb fails for any reason but
+// not-found, stale details are served rather than the error; when the
+// table itself fails, the call falls through to TMDb. The table holds
+// TMDb's default (English) details; other languages always go to TMDb.
+func (c *Cache) GetMovie(ctx context.Context, id int64) (*tmdb.Movie, error) {
+	if i18n.FromContext(ctx).Language != "" {
+		return c.Client.GetMovie(ctx, id)
+	}
+	row, err := c.Store.Movie(ctx, id)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.WarnContext(ctx, "movie cache read failed", slog.Int64("tmdb_id", id), slog.Any("err", err))
+		}
+		c.misses.Add(1)
+		return c.Fetch(ctx, id)
+	}
+	cached, decodeErr := decode(row)
+	if decodeErr == nil && time.Since(row.FetchedAt) < c.TTL {
+		c.hits.Add(1)
+		return cached, nil
+	}
+	c.misses.Add(1)
+	mv, err := c.Fetch(ctx, id)
+	if err != nil && decodeErr == nil && !errors.Is(err, tmdb.ErrNotFound) {
+		slog.WarnContext(ctx, "serving stale movie details", slog.Int64("tmdb_id", id), slog.Any("err", err))
+		return cached, nil
+	}
+	return mv, err
+}
+
+// Refresh refetches up to Batch entries older than TTL, oldest first. One
+// movie failing does not stop the rest, but an unavailable TMDb ends the
+// run early. Movies TMDb no longer has keep their last details and are
+// marked fetched so they do not hold up the queue.
+func (c *Cache) Refresh(ctx context.Context) error {
+	ids, err := c.Store.StaleMovies(ctx, time.Now().Add(-c.TTL), c.Batch)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		_, err := c.Fetch(ctx, id)
+		switch {
+		case err == nil:
+		case errors.Is(err, tmdb.ErrNotFound):
+			if err := c.touch(ctx, id); err != nil {
+				errs = append(errs, fmt.Errorf("movie %d: %w", id, err))
+			}
+		case errors.Is(err, tmdb.ErrUnavailable), errors.Is(err, tmdb.ErrRateLimited):
+			return errors.Join(append(errs, fmt.Errorf("movie %d: %w", id, err))...)
+		default:
+			errs = append(errs, fmt.Errorf("movie %d: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Fetch gets a movie from TMDb whatever its age and stores it. Failing to
+// store is logged and otherwise ignored; the caller still gets the movie.
+// It always asks for the default language, which is what the table holds.
+func (c *Cache) Fetch(ctx context.Context, id int64) (*tmdb.Movie, error) {
+	mv, err := c.Client.GetMovie(i18n.NewContext(ctx, i18n.Locale{}), id)
+	if err != nil {
+		return nil, err
+	}
+	details, err := json.Marshal(mv)
+	if err == nil {
+		err = c.Store.SaveMovie(ctx, &models.Movie{TMDBID: id, Title: mv.Title, PosterPath: mv.PosterPath, ReleaseDate: mv.ReleaseDate, Details: details, FetchedAt: time.Now()})
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "movie cache write failed", slog.Int64("tmdb_id", id), slog.Any("err", err))
+	}
+	return mv, nil
+}
+
+// touch marks a movie as just fetched without changing its details.
+func (c *Cache) touch(ctx context.Context, id int64) error {
+	row, err := c.Store.Movie(ctx, id)
+	if err != nil {
+		return err
+	}
+	row.FetchedAt = time.Now()
+	return c.Store.SaveMovie(ctx, row)
+}
+
+func decode(row *models.Movie) (*tmdb.Movie, error) {
+	var mv tmdb.Movie
+	if err := json.Unmarshal(row.Details, &mv); err != nil {
+		return nil, fmt.Errorf("moviecache: decode movie %d: %w", row.TMDBID, err)
+	}
+	return &mv, nil
+}
@@ -0,0 +1,165 @@
+// Package openapi builds the OpenAPI 3.1 document for the API from a route
+// table and the Go types handlers decode into, checks it against the chi
+// router, and optionally validates incoming requests against it.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps a lowercase HTTP method to its operation.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Route describes one operation. Query, Body and Response are zero values
+// of the types the handler reads and writes; Query fields are named by
+// their `query` tag, Body and Response fields by their `json` tag.
+type Route struct {
+	Method   string
+	Path     string
+	ID       string
+	Summary  string
+	Tag      string
+	Auth     bool
+	Query    any
+	Body     any
+	Response any
+	// Status is the success status; 200 when zero.
+	Status int
+	// PathTypes overrides the schema type of path parameters (string by default).
+	PathTypes map[string]string
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Build assembles the document for routes.
+func Build(info Info, routes []Route) *Document {
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	g := &generator{schemas: doc.Components.Schemas}
+	problemRef := g.schemaFor(problemType)
+	for _, rt := range routes {
+		op := &Operation{OperationID: rt.ID, Summary: rt.Summary, Responses: map[string]Response{}}
+		if rt.Tag != "" {
+			op.Tags = []string{rt.Tag}
+		}
+		if rt.Auth {
+			op.Security = []map[string][]string{{"bearer": {}}}
+		}
+		for _, m := range pathParam.FindAllStringSubmatch(rt.Path, -1) {
+			typ := rt.PathTypes[m[1]]
+			if typ == "" {
+				typ = "string"
+			}
+			op.Parameters = append(op.Parameters, Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: typ}})
+		}
+		if rt.Query != nil {
+			op.Parameters = append(op.Parameters, g.queryParams(rt.Query)...)
+		}
+		if rt.Body != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: g.schemaFor(rt.Body)}}}
+		}
+		status := rt.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		ok := Response{Description: http.StatusText(status)}
+		if rt.Response != nil {
+			ok.Content = map[string]MediaType{"application/json": {Schema: g.schemaFor(rt.Response)}}
+		}
+		op.Responses[strconv.Itoa(status)] = ok
+		op.Responses["default"] = Response{Description: "Error", Content: map[string]MediaType{"application/problem+json": {Schema: problemRef}}}
+
+		item := doc.Paths[rt.Path]
+		if item == nil {
+			item = PathItem{}
+			doc.Paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+	return doc
+}
+
+// Operations lists "METHOD path" for every operation, sorted.
+func (d *Document) Operations() []string {
+	var out []string
+	for path, item := range d.Paths {
+		for method := range item {
+			out = append(out, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,230 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yourname/moodle/internal/i18n"
+	"github.com/yourname/moodle/internal/problem"
+)
+
+// maxValidatedBody bounds how much request body the validator will buffer.
+const maxValidatedBody = 1 << 20
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type matcher struct {
+	re   *regexp.Regexp
+	item PathItem
+}
+
+// Validator rejects requests whose query parameters or JSON body do not
+// match the document, answering with a validation problem. prefix is the
+// mount point of the documented paths (the server URL, e.g. /v1).
+// Requests for undocumented paths pass through untouched.
+func Validator(doc *Document, prefix string) func(http.Handler) http.Handler {
+	var matchers []matcher
+	for path, item := range doc.Paths {
+		segs := strings.Split(path, "/")
+		for i, seg := range segs {
+			if pathParam.MatchString(seg) {
+				segs[i] = `[^/]+`
+			} else {
+				segs[i] = regexp.QuoteMeta(seg)
+			}
+		}
+		expr := "^" + strings.Join(segs, "/") + "/?$"
+		matchers = append(matchers, matcher{re: regexp.MustCompile(expr), item: item})
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := strings.TrimPrefix(r.URL.Path, prefix)
+			var op *Operation
+			for _, m := range matchers {
+				if m.re.MatchString(path) {
+					op = m.item[strings.ToLower(r.Method)]
+					break
+				}
+			}
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			v := &validator{schemas: doc.Components.Schemas, errs: map[string]string{}, loc: i18n.FromContext(r.Context())}
+			q := r.URL.Query()
+			for _, p := range op.Parameters {
+				if p.In != "query" {
+					continue
+				}
+				raw, present := q[p.Name]
+				if !present || raw[0] == "" {
+					if p.Required {
+						v.errs[p.Name] = v.loc.Text("is required")
+					}
+					continue
+				}
+				v.param(p.Name, raw[0], p.Schema)
+			}
+			if op.RequestBody != nil {
+				body, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBody+1))
+				if err != nil || len(body) > maxValidatedBody {
+					problem.BadRequest(w, r, "request body too large or unreadable")
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				var decoded any
+				if err := json.Unmarshal(body, &decoded); err != nil {
+					problem.InvalidJSON(w, r)
+					return
+				}
+				v.value("", decoded, op.RequestBody.Content["application/json"].Schema)
+			}
+			if len(v.errs) > 0 {
+				problem.Validation(w, r, v.errs)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type validator struct {
+	schemas map[string]*Schema
+	errs    map[string]string
+	// loc is the language messages are written in.
+	loc i18n.Locale
+}
+
+func (v *validator) resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = v.schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+// param checks a query string value, converting numbers first.
+func (v *validator) param(name, raw string, s *Schema) {
+	switch s.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			v.errs[name] = v.loc.Text("must be an integer")
+			return
+		}
+		v.value(name, float64(n), s)
+	case "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			v.errs[name] = v.loc.Text("must be a number")
+			return
+		}
+		v.value(name, f, s)
+	default:
+		v.value(name, raw, s)
+	}
+}
+
+// value checks a decoded JSON value against s, recording errors by path.
+func (v *validator) value(path string, val any, s *Schema) {
+	s = v.resolve(s)
+	if s == nil || val == nil {
+		return
+	}
+	field := path
+	if field == "" {
+		field = "body"
+	}
+	switch s.Type {
+	case "object":
+		obj, ok := val.(map[string]any)
+		if !ok {
+			v.errs[field] = v.loc.Text("must be an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				v.errs[join(path, name)] = v.loc.Text("is required")
+			}
+		}
+		for name, fv := range obj {
+			sub, ok := s.Properties[name]
+			switch {
+			case ok:
+				v.value(join(path, name), fv, sub)
+			case s.AdditionalProperties != nil:
+				v.value(join(path, name), fv, s.AdditionalProperties)
+			case len(s.Properties) > 0:
+				// A misspelt field would otherwise be dropped silently.
+				v.errs[join(path, name)] = v.loc.Text("is not allowed")
+			}
+		}
+	case "array":
+		arr, ok := val.([]any)
+		if !ok {
+			v.errs[field] = v.loc.Text("must be an array")
+			return
+		}
+		for i, item := range arr {
+			v.value(fmt.Sprintf("%s[%d]", field, i), item, s.Items)
+		}
+	case "string":
+		str, ok := val.(string)
+		if !ok {
+			v.errs[field] = v.loc.Text("must be a string")
+			return
+		}
+		n := len([]rune(str))
+		switch {
+		case s.MinLength != nil && n < *s.MinLength:
+			v.errs[field] = v.loc.Sprintf("must be at least %v characters", *s.MinLength)
+		case s.MaxLength != nil && n > *s.MaxLength:
+			v.errs[field] = v.loc.Sprintf("must be at most %v characters", *s.MaxLength)
+		case len(s.Enum) > 0 && !contains(s.Enum, str):
+			v.errs[field] = v.loc.Sprintf("must be one of %v", strings.Join(s.Enum, " "))
+		case s.Format == "uuid" && !uuidPattern.MatchString(str):
+			v.errs[field] = v.loc.Text("must be a UUID")
+		}
+	case "integer", "number":
+		f, ok := val.(float64)
+		if !ok {
+			v.errs[field] = v.loc.Text("must be a number")
+			return
+		}
+		switch {
+		case s.Type == "integer" && f != float64(int64(f)):
+			v.errs[field] = v.loc.Text("must be an integer")
+		case s.Minimum != nil && f < *s.Minimum:
+			v.errs[field] = v.loc.Sprintf("must be >= %v", *s.Minimum)
+		case s.Maximum != nil && f > *s.Maximum:
+			v.errs[field] = v.loc.Sprintf("must be <= %v", *s.Maximum)
+		case s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum:
+			v.errs[field] = v.loc.Sprintf("must be > %v", *s.ExclusiveMinimum)
+		}
+	case "boolean":
+		if _, ok := val.(bool); !ok {
+			v.errs[field] = v.loc.Text("must be a boolean")
+		}
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package openapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourname/moodle/internal/i18n"
+	"github.com/yourname/moodle/internal/openapi"
+	"github.com/yourname/moodle/internal/problem"
+)
+
+type searchQuery struct {
+	Q    string `query:"q" validate:"required"`
+	Page int    `query:"page" validate:"omitempty,gte=1,lte=1000"`
+}
+
+type itemBody struct {
+	TMDBID int64  `json:"tmdb_id" validate:"required,gt=0"`
+	Media  string `json:"media_type" validate:"omitempty,oneof=movie tv"`
+}
+
+type listBody struct {
+	Title    string            `json:"title" validate:"required,min=1,max=10"`
+	IsPublic bool              `json:"is_public"`
+	Items    []itemBody        `json:"items"`
+	Labels   map[string]string `json:"labels"`
+}
+
+func TestValidator(t *testing.T) {
+	doc := openapi.Build(openapi.Info{Title: "test", Version: "1"}, []openapi.Route{
+		{Method: "GET", Path: "/search", ID: "search", Query: searchQuery{}},
+		{Method: "POST", Path: "/lists/{id}", ID: "createList", Body: listBody{}},
+	})
+	var got string
+	h := openapi.Validator(doc, "/v1")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		lang   string
+		errs   map[string]string
+	}{
+		{"valid query", "GET", "/v1/search?q=alien&page=2", "", "", nil},
+		{"missing query", "GET", "/v1/search", "", "", map[string]string{"q": "is required"}},
+		{"bad query type", "GET", "/v1/search?q=alien&page=two", "", "", map[string]string{"page": "must be an integer"}},
+		{"query out of range", "GET", "/v1/search?q=alien&page=0", "", "", map[string]string{"page": "must be >= 1"}},
+		{"translated", "GET", "/v1/search", "", "fr", map[string]string{"q": "est obligatoire"}},
+		{"valid body", "POST", "/v1/lists/1", `{"title":"Tonight","items":[{"tmdb_id":550}],"labels":{"mood":"cosy"}}`, "", nil},
+		{"bad body", "POST", "/v1/lists/1", `{"title":"A much too long title","is_public":"yes","items":[{"tmdb_id":0,"media_type":"book"}]}`, "", map[string]string{
+			"title":               "must be at most 10 characters",
+			"is_public":           "must be a boolean",
+			"items[0].tmdb_id":    "must be > 0",
+			"items[0].media_type": "must be one of movie tv",
+		}},
+		{"unknown field", "POST", "/v1/lists/1", `{"title":"Tonight","titel":"x","items":[{"tmdb_id":550,"note":"x"}]}`, "", map[string]string{
+			"titel":         "is not allowed",
+			"items[0].note": "is not allowed",
+		}},
+		{"missing body field", "POST", "/v1/lists/1", `{}`, "", map[string]string{"title": "is required"}},
+		{"undocumented path", "POST", "/v1/other", `{"anything":true}`, "", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got = ""
+			r := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+			if c.lang != "" {
+				r = r.WithContext(i18n.NewContext(context.Background(), i18n.Parse(c.lang)))
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if c.errs == nil {
+				if w.Code != http.StatusNoContent || got != c.body {
+					t.Fatalf("status %d, handler read %q; want the request passed through intact", w.Code, got)
+				}
+				return
+			}
+			if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Fatalf("status %d, Content-Type %q; want a 400 problem", w.Code, w.Header().Get("Content-Type"))
+			}
+			var p problem.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Type != problem.TypeValidation || len(p.Errors) != len(c.errs) {
+				t.Fatalf("problem = %+v, want errors %v", p, c.errs)
+			}
+			for k, v := range c.errs {
+				if p.Errors[k] != v {
+					t.Errorf("errors[%q] = %q, want %q", k, p.Errors[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatorRejectsMalformedJSON(t *testing.T) {
+	doc := openapi.Build(openapi.Info{Title: "test", Version: "1"}, []openapi.Route{{Method: "POST", Path: "/lists/{id}", ID: "createList", Body: listBody{}}})
+	h := openapi.Validator(doc, "/v1")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler ran for malformed JSON")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/v1/lists/1", strings.NewReader(`{"title":`)))
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("status %d, Content-Type %q; want a 400 problem", w.Code, w.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Verify reports routes served by r under prefix that the document does
+// not describe, and documented operations r does not serve. Paths are
+// compared without trailing slashes.
+func Verify(doc *Document, r chi.Routes, prefix string) error {
+	served := map[string]bool{}
+	err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, prefix) || method == http.MethodOptions || method == http.MethodHead {
+			return nil
+		}
+		served[method+" "+normalize(strings.TrimPrefix(route, prefix))] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	documented := map[string]bool{}
+	for _, op := range doc.Operations() {
+		method, path, _ := strings.Cut(op, " ")
+		documented[method+" "+normalize(path)] = true
+	}
+	var missing, stale []string
+	for op := range served {
+		if !documented[op] {
+			missing = append(missing, op)
+		}
+	}
+	for op := range documented {
+		if !served[op] {
+			stale = append(stale, op)
+		}
+	}
+	if len(missing) == 0 && len(stale) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+	return fmt.Errorf("openapi spec out of sync with router: undocumented %v, not served %v", missing, stale)
+}
+
+func normalize(path string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
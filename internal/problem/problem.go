@@ -0,0 +1,110 @@
+// Package problem writes RFC 7807 application/problem+json error responses.
+package problem
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/yourname/moodle/internal/i18n"
+)
+
+// Stable problem type codes. Clients switch on these, so never rename one.
+const (
+	TypeInvalidJSON        = "invalid_json"
+	TypeValidation         = "validation_failed"
+	TypeBadRequest         = "bad_request"
+	TypeUnauthorized       = "unauthorized"
+	TypeForbidden          = "forbidden"
+	TypeNotFound           = "not_found"
+	TypeMethodNotAllowed   = "method_not_allowed"
+	TypeConflict           = "conflict"
+	TypeUnprocessable      = "unprocessable"
+	TypePayloadTooLarge    = "payload_too_large"
+	TypeRateLimited        = "rate_limited"
+	TypeContentRejected    = "content_rejected"
+	TypeUpstream           = "upstream_error"
+	TypeInternal           = "internal_error"
+	TypeServiceUnavailable = "service_unavailable"
+)
+
+// ContentType is the media type of every problem response.
+const ContentType = "application/problem+json"
+
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors holds field-level messages for validation failures.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Write sends p, filling in the title, instance and request ID when unset.
+// The title, detail and field messages are translated into the request's
+// language when its catalog has them; callers building a message from
+// parts translate it themselves with i18n.T.
+func Write(w http.ResponseWriter, r *http.Request, p Problem) {
+	loc := i18n.FromContext(r.Context())
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	p.Title, p.Detail = loc.Text(p.Title), loc.Text(p.Detail)
+	if p.Errors != nil {
+		errs := make(map[string]string, len(p.Errors))
+		for field, msg := range p.Errors {
+			errs[field] = loc.Text(msg)
+		}
+		p.Errors = errs
+	}
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = middleware.GetReqID(r.Context())
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+func BadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	Write(w, r, Problem{Type: TypeBadRequest, Status: http.StatusBadRequest, Detail: detail})
+}
+
+func InvalidJSON(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, Problem{Type: TypeInvalidJSON, Status: http.StatusBadRequest, Detail: "request body is not valid JSON"})
+}
+
+// Validation reports field->message errors, as returned by validate.Map.
+func Validation(w http.ResponseWriter, r *http.Request, errs map[string]string) {
+	Write(w, r, Problem{Type: TypeValidation, Status: http.StatusBadRequest, Detail: "one or more fields are invalid", Errors: errs})
+}
+
+func Unauthorized(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, Problem{Type: TypeUnauthorized, Status: http.StatusUnauthorized, Detail: "authentication required"})
+}
+
+func Forbidden(w http.ResponseWriter, r *http.Request, detail string) {
+	Write(w, r, Problem{Type: TypeForbidden, Status: http.StatusForbidden, Detail: detail})
+}
+
+func NotFound(w http.ResponseWriter, r *http.Request, detail string) {
+	Write(w, r, Problem{Type: TypeNotFound, Status: http.StatusNotFound, Detail: detail})
+}
+
+// Internal logs err and sends a 500 that does not leak it.
+func Internal(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "internal error", slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.Any("err", err))
+	Write(w, r, Problem{Type: TypeInternal, Status: http.StatusInternalServerError, Detail: "something went wrong"})
+}
+
+// Upstream logs err from a third-party service and sends a 502 naming only the service.
+func Upstream(w http.ResponseWriter, r *http.Request, service string, err error) {
+	slog.ErrorContext(r.Context(), "upstream error", slog.String("service", service), slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.Any("err", err))
+	Write(w, r, Problem{Type: TypeUpstream, Status: http.StatusBadGateway, Detail: i18n.T(r.Context(), "%s request failed", service)})
+}
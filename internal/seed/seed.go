@@ -0,0 +1,241 @@
+// Package seed fills a development database with users, watchlists, items,
+// likes and shares. Movies come from a bundled TMDb fixture so seeding
+// needs no network, and the same Config, with Now set, always produces the
+// same data.
+package seed
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/tmdb"
+)
+
+// EmailDomain marks seeded users so Clean can find them again.
+const EmailDomain = "seed.moodle.test"
+
+//go:embed fixtures/movies.json
+var moviesJSON []byte
+
+// Movies returns the bundled fixture: a page of TMDb movie results,
+// most popular first.
+func Movies() ([]tmdb.Movie, error) {
+	var page tmdb.TrendingResponse
+	if err := json.Unmarshal(moviesJSON, &page); err != nil {
+		return nil, fmt.Errorf("seed fixture: %w", err)
+	}
+	return page.Results, nil
+}
+
+// Config sizes the generated data.
+type Config struct {
+	Seed       uint64
+	Users      int
+	Watchlists int
+	// ItemsPerList is the mean list length; lengths are spread exponentially.
+	ItemsPerList int
+	Likes        int
+	Shares       int
+	// Now anchors generated timestamps; zero means time.Now, which keeps
+	// recent likes recent but makes timestamps differ between runs.
+	Now time.Time
+}
+
+// Dataset is everything Generate produced, ready to insert.
+type Dataset struct {
+	Users      []models.User
+	Watchlists []models.Watchlist
+	Items      []models.WatchlistItem
+	Likes      []models.Like
+	Shares     []models.Share
+}
+
+const history = 90 * 24 * time.Hour
+
+var (
+	firstNames = []string{"ada", "ben", "chloe", "dev", "emma", "felix", "grace", "hugo", "iris", "jonas", "kira", "leo", "maya", "nico", "olga", "priya", "quinn", "rosa", "sam", "tariq", "uma", "victor", "wen", "yara", "zoe"}
+	listNames  = []string{"Weekend picks", "Date night", "Comfort movies", "Rainy day", "Mind benders", "Classics to finally watch", "Family night", "Best of the 90s", "Sci-fi essentials", "Animated favourites", "Cry guaranteed", "Watch with dad", "Film club", "Top shelf", "Summer backlog"}
+	notes      = []string{"", "", "", "rewatch", "recommended by a friend", "watch in cinema if possible", "the director's cut", "subtitles on"}
+	messages   = []string{"", "thought you'd like this", "for movie night", "you have to see these"}
+)
+
+// Generate builds a dataset from movies. Owners, list lengths, movie picks
+// and likes follow Zipf-like distributions: a few users own most lists,
+// popular movies show up on many lists and a few lists collect most likes.
+func Generate(c Config, movies []tmdb.Movie) (*Dataset, error) {
+	if c.Users < 2 {
+		return nil, fmt.Errorf("seed: need at least 2 users, got %d", c.Users)
+	}
+	if len(movies) == 0 {
+		return nil, fmt.Errorf("seed: no movies")
+	}
+	if c.Now.IsZero() {
+		c.Now = time.Now()
+	}
+	r := rand.New(rand.NewPCG(c.Seed, c.Seed^0x9e3779b97f4a7c15))
+	g := &generator{r: r, now: c.Now.UTC()}
+	ds := &Dataset{}
+
+	for i := 0; i < c.Users; i++ {
+		name := fmt.Sprintf("%s%d", firstNames[i%len(firstNames)], i)
+		created := g.pastTime(history)
+		ds.Users = append(ds.Users, models.User{
+			ID: g.uuid(), CreatedAt: created, UpdatedAt: created,
+			Email: name + "@" + EmailDomain, Username: name,
+		})
+	}
+
+	owners := rand.NewZipf(r, 1.3, 2, uint64(c.Users-1))
+	picks := rand.NewZipf(r, 1.1, 3, uint64(len(movies)-1))
+	for i := 0; i < c.Watchlists; i++ {
+		owner := ds.Users[owners.Uint64()]
+		created := g.after(owner.CreatedAt)
+		wl := models.Watchlist{
+			ID: g.uuid(), CreatedAt: created, UpdatedAt: created,
+			OwnerID: owner.ID, Title: listNames[r.IntN(len(listNames))],
+			IsPublic: r.Float64() < 0.8, ModerationStatus: models.ModerationOK,
+		}
+		ds.Watchlists = append(ds.Watchlists, wl)
+
+		n := 1 + int(r.ExpFloat64()*float64(max(c.ItemsPerList-1, 0)))
+		n = min(n, len(movies))
+		seen := map[int64]bool{}
+		for pos := 0; len(seen) < n && pos < 4*n; pos++ {
+			mv := movies[picks.Uint64()]
+			if seen[mv.ID] {
+				continue
+			}
+			seen[mv.ID] = true
+			added := g.after(created)
+			ds.Items = append(ds.Items, models.WatchlistItem{
+				ID: g.uuid(), CreatedAt: added, UpdatedAt: added,
+				WatchlistID: wl.ID, MediaType: models.MediaMovie, TMDBID: mv.ID, Title: mv.Title, PosterPath: mv.PosterPath, ReleaseDate: mv.ReleaseDate,
+				Notes: notes[r.IntN(len(notes))], Position: len(seen) - 1, ModerationStatus: models.ModerationOK,
+			})
+		}
+	}
+
+	var public []models.Watchlist
+	for _, wl := range ds.Watchlists {
+		if wl.IsPublic {
+			public = append(public, wl)
+		}
+	}
+	if len(public) > 0 {
+		targets := rand.NewZipf(r, 1.2, 2, uint64(len(public)-1))
+		liked := map[[2]string]bool{}
+		for tries := 0; len(ds.Likes) < c.Likes && tries < 10*c.Likes; tries++ {
+			wl := public[targets.Uint64()]
+			user := ds.Users[r.IntN(len(ds.Users))]
+			key := [2]string{user.ID, wl.ID}
+			if user.ID == wl.OwnerID || liked[key] {
+				continue
+			}
+			liked[key] = true
+			// Recent-heavy so the weekly and monthly trending windows differ.
+			ds.Likes = append(ds.Likes, models.Like{ID: g.uuid(), CreatedAt: g.recent(wl.CreatedAt), UserID: user.ID, WatchlistID: wl.ID})
+		}
+	}
+
+	for i := 0; i < c.Shares && len(ds.Watchlists) > 0; i++ {
+		wl := ds.Watchlists[r.IntN(len(ds.Watchlists))]
+		from := wl.OwnerID
+		if wl.IsPublic && r.Float64() < 0.5 {
+			from = ds.Users[r.IntN(len(ds.Users))].ID
+		}
+		to := ds.Users[r.IntN(len(ds.Users))].ID
+		if to == from {
+			continue
+		}
+		ds.Shares = append(ds.Shares, models.Share{
+			ID: g.uuid(), CreatedAt: g.after(wl.CreatedAt), FromUserID: from, ToUserID: to, WatchlistID: wl.ID,
+			Message: messages[r.IntN(len(messages))],
+		})
+	}
+	return ds, nil
+}
+
+type generator struct {
+	r   *rand.Rand
+	now time.Time
+}
+
+// uuid returns a random (version 4) UUID drawn from the seeded source.
+func (g *generator) uuid() string {
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(g.r.UintN(256))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func (g *generator) pastTime(within time.Duration) time.Time {
+	return g.now.Add(-time.Duration(g.r.Int64N(int64(within)))).Truncate(time.Second)
+}
+
+// after returns a uniform time between t and now.
+func (g *generator) after(t time.Time) time.Time {
+	span := g.now.Sub(t)
+	if span <= 0 {
+		return t
+	}
+	return t.Add(time.Duration(g.r.Int64N(int64(span)))).Truncate(time.Second)
+}
+
+// recent returns a time after t, exponentially biased towards now with a
+// mean age of ten days.
+func (g *generator) recent(t time.Time) time.Time {
+	age := time.Duration(math.Min(g.r.ExpFloat64()*10*24, float64(history/time.Hour))) * time.Hour
+	at := g.now.Add(-age)
+	if at.Before(t) {
+		return g.after(t)
+	}
+	return at.Truncate(time.Second)
+}
+
+// Insert writes ds in one transaction.
+func Insert(ctx context.Context, db *gorm.DB, ds *Dataset) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(ds.Users, 500).Error; err != nil {
+			return err
+		}
+		if len(ds.Watchlists) > 0 {
+			if err := tx.Omit("Items").CreateInBatches(ds.Watchlists, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(ds.Items) > 0 {
+			if err := tx.CreateInBatches(ds.Items, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(ds.Likes) > 0 {
+			if err := tx.CreateInBatches(ds.Likes, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(ds.Shares) > 0 {
+			if err := tx.CreateInBatches(ds.Shares, 500).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Clean hard-deletes previously seeded users; their lists, items, likes
+// and shares go with them through ON DELETE CASCADE.
+func Clean(ctx context.Context, db *gorm.DB) (int64, error) {
+	res := db.WithContext(ctx).Unscoped().Where("email LIKE ?", "%@"+EmailDomain).Delete(&models.User{})
+	return res.RowsAffected, res.Error
+}
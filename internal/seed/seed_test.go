@@ -0,0 +1,38 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	movies, err := Movies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Config{Seed: 7, Users: 20, Watchlists: 40, ItemsPerList: 6, Likes: 200, Shares: 20, Now: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	a, err := Generate(c, movies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Generate(c, movies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("two Generate calls with the same Config differ")
+	}
+	if len(a.Users) != 20 || len(a.Watchlists) != 40 || len(a.Items) == 0 || len(a.Likes) == 0 || len(a.Shares) == 0 {
+		t.Fatalf("dataset sizes: %d users, %d lists, %d items, %d likes, %d shares", len(a.Users), len(a.Watchlists), len(a.Items), len(a.Likes), len(a.Shares))
+	}
+
+	c.Seed = 8
+	other, err := Generate(c, movies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Users[0].ID == a.Users[0].ID {
+		t.Fatal("a different seed gave the same IDs")
+	}
+}
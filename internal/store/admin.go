@@ -0,0 +1,226 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/yourname/moodle/internal/models"
+)
+
+// Admin operations used by moodlectl. They skip the per-viewer visibility
+// rules the API applies.
+
+// WatchlistFilter narrows ListWatchlists; zero values match everything.
+type WatchlistFilter struct {
+	OwnerID string
+	Query   string // case-insensitive match on title
+	Limit   int
+}
+
+// WatchlistSummary is a list with its counts for admin listings.
+type WatchlistSummary struct {
+	models.Watchlist
+	ItemCount  int64 `json:"item_count"`
+	LikeCount  int64 `json:"like_count"`
+	ShareCount int64 `json:"share_count"`
+}
+
+func (s *Store) ListWatchlists(ctx context.Context, f WatchlistFilter) ([]WatchlistSummary, error) {
+	q := s.DB.WithContext(ctx).Table("watchlists w").Select(`w.*,
+		(SELECT COUNT(*) FROM watchlist_items i WHERE i.watchlist_id = w.id AND i.deleted_at IS NULL) AS item_count,
+		(SELECT COUNT(*) FROM likes l WHERE l.watchlist_id = w.id) AS like_count,
+		(SELECT COUNT(*) FROM shares sh WHERE sh.watchlist_id = w.id) AS share_count`).
+		Where("w.deleted_at IS NULL")
+	if f.OwnerID != "" {
+		q = q.Where("w.owner_id = ?", f.OwnerID)
+	}
+	if f.Query != "" {
+		q = q.Where("w.title ILIKE ?", "%"+f.Query+"%")
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var out []WatchlistSummary
+	if err := q.Order("w.created_at DESC").Scan(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InspectWatchlist returns a list with all of its items, including those
+// held for review.
+func (s *Store) InspectWatchlist(ctx context.Context, id string) (*WatchlistSummary, error) {
+	var wl models.Watchlist
+	if err := s.DB.WithContext(ctx).Preload("Items", func(tx *gorm.DB) *gorm.DB { return tx.Order("position ASC") }).First(&wl, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	out := &WatchlistSummary{Watchlist: wl, ItemCount: int64(len(wl.Items))}
+	db := s.DB.WithContext(ctx)
+	if err := db.Model(&models.Like{}).Where("watchlist_id = ?", id).Count(&out.LikeCount).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Share{}).Where("watchlist_id = ?", id).Count(&out.ShareCount).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TransferWatchlist makes newOwner the owner of list id.
+func (s *Store) TransferWatchlist(ctx context.Context, id, newOwner string) error {
+	return s.WithTx(ctx, func(tx *Store) error {
+		if _, err := tx.GetUser(ctx, newOwner); err != nil {
+			return err
+		}
+		res := tx.DB.WithContext(ctx).Model(&models.Watchlist{}).Where("id = ?", id).Update("owner_id", newOwner)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// MergeUsers moves everything dup owns or did onto keep and soft-deletes
+// dup. Likes, blocks and mutes that keep already has, or that would point
+// keep at itself, are dropped rather than duplicated.
+func (s *Store) MergeUsers(ctx context.Context, keep, dup string) error {
+	if keep == dup {
+		return errors.New("cannot merge a user into itself")
+	}
+	return s.WithTx(ctx, func(st *Store) error {
+		if _, err := st.GetUser(ctx, keep); err != nil {
+			return err
+		}
+		if _, err := st.GetUser(ctx, dup); err != nil {
+			return err
+		}
+		tx := st.DB.WithContext(ctx)
+		if err := tx.Unscoped().Model(&models.Watchlist{}).Where("owner_id = ?", dup).Update("owner_id", keep).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DELETE FROM likes WHERE user_id = @dup AND watchlist_id IN (SELECT watchlist_id FROM likes WHERE user_id = @keep)`,
+			map[string]any{"dup": dup, "keep": keep}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Like{}).Where("user_id = ?", dup).Update("user_id", keep).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Share{}).Where("from_user_id = ?", dup).Update("from_user_id", keep).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Share{}).Where("to_user_id = ?", dup).Update("to_user_id", keep).Error; err != nil {
+			return err
+		}
+		if err := mergeRelation(tx, "blocks", "blocker_id", "blocked_id", keep, dup); err != nil {
+			return err
+		}
+		if err := mergeRelation(tx, "mutes", "muter_id", "muted_id", keep, dup); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.APIToken{}).Where("user_id = ?", dup).Update("user_id", keep).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.User{}, "id = ?", dup).Error
+	})
+}
+
+// mergeRelation repoints a (from, to) pair table from dup to keep, first
+// deleting rows that would collide with keep's own or relate keep to itself.
+func mergeRelation(tx *gorm.DB, table, from, to, keep, dup string) error {
+	args := map[string]any{"dup": dup, "keep": keep}
+	stmts := []string{
+		fmt.Sprintf(`DELETE FROM %[1]s WHERE %[2]s = @dup AND (%[3]s = @keep OR %[3]s IN (SELECT %[3]s FROM %[1]s WHERE %[2]s = @keep))`, table, from, to),
+		fmt.Sprintf(`DELETE FROM %[1]s WHERE %[3]s = @dup AND (%[2]s = @keep OR %[2]s IN (SELECT %[2]s FROM %[1]s WHERE %[3]s = @keep))`, table, from, to),
+		fmt.Sprintf(`UPDATE %s SET %s = @keep WHERE %[2]s = @dup`, table, from),
+		fmt.Sprintf(`UPDATE %s SET %s = @keep WHERE %[2]s = @dup`, table, to),
+	}
+	for _, q := range stmts {
+		if err := tx.Exec(q, args).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ItemTMDBIDs lists the distinct movies on watchlist items, or on one list
+// when watchlistID is set. TV items are left out.
+func (s *Store) ItemTMDBIDs(ctx context.Context, watchlistID string) ([]int64, error) {
+	q := s.DB.WithContext(ctx).Model(&models.WatchlistItem{}).Distinct("tmdb_id").Where("media_type = ?", models.MediaMovie)
+	if watchlistID != "" {
+		q = q.Where("watchlist_id = ?", watchlistID)
+	}
+	var ids []int64
+	if err := q.Order("tmdb_id").Pluck("tmdb_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// UpdateItemMetadata rewrites the copied TMDb fields on every item for a
+// movie, but not a show with the same ID, and reports how many items
+// changed.
+func (s *Store) UpdateItemMetadata(ctx context.Context, tmdbID int64, title, posterPath, releaseDate string) (int64, error) {
+	res := s.DB.WithContext(ctx).Model(&models.WatchlistItem{}).
+		Where("media_type = ? AND tmdb_id = ? AND (title IS DISTINCT FROM ? OR poster_path IS DISTINCT FROM ? OR release_date IS DISTINCT FROM ?)", models.MediaMovie, tmdbID, title, posterPath, releaseDate).
+		Updates(map[string]any{"title": title, "poster_path": posterPath, "release_date": releaseDate})
+	return res.RowsAffected, res.Error
+}
+
+// ReviewQueue is the content waiting for a moderator, oldest first.
+type ReviewQueue struct {
+	Watchlists []models.Watchlist     `json:"watchlists"`
+	Items      []models.WatchlistItem `json:"items"`
+}
+
+// PendingReview returns up to limit lists and up to limit items held for
+// review. Items on deleted lists are left out with the lists.
+func (s *Store) PendingReview(ctx context.Context, limit int) (*ReviewQueue, error) {
+	q := &ReviewQueue{Watchlists: []models.Watchlist{}, Items: []models.WatchlistItem{}}
+	db := s.DB.WithContext(ctx)
+	if err := db.Where("moderation_status = ?", models.ModerationPendingReview).Order("created_at ASC").Limit(limit).Find(&q.Watchlists).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("moderation_status = ?", models.ModerationPendingReview).Order("created_at ASC").Limit(limit).Find(&q.Items).Error; err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// ReviewWatchlist records a moderator's decision on list id: ModerationOK
+// publishes it again, ModerationRejected keeps it hidden from everyone but
+// its owner.
+func (s *Store) ReviewWatchlist(ctx context.Context, id, status string) error {
+	return s.review(ctx, &models.Watchlist{}, id, status)
+}
+
+// ReviewItem is ReviewWatchlist for one item's notes.
+func (s *Store) ReviewItem(ctx context.Context, id, status string) error {
+	return s.review(ctx, &models.WatchlistItem{}, id, status)
+}
+
+func (s *Store) review(ctx context.Context, model any, id, status string) error {
+	if err := CheckReview(status); err != nil {
+		return err
+	}
+	res := s.DB.WithContext(ctx).Model(model).Where("id = ?", id).Update("moderation_status", status)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CheckReview reports whether status is a decision a moderator can make.
+func CheckReview(status string) error {
+	if status != models.ModerationOK && status != models.ModerationRejected {
+		return fmt.Errorf("moderation status %q: want %s or %s", status, models.ModerationOK, models.ModerationRejected)
+	}
+	return nil
+}
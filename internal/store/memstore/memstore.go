@@ -0,0 +1,599 @@
+// Package memstore is an in-memory implementation of the store used by the
+// handlers. It follows the Postgres store's rules for ownership,
+// visibility, blocks, mutes, moderation, soft-deletes and like uniqueness,
+// and reports missing rows with gorm.ErrRecordNotFound like the real one,
+// so handler tests can run without a database. storetest checks the two
+// agree.
+package memstore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/store"
+	"github.com/yourname/moodle/internal/store/storetest"
+)
+
+// Store keeps every table in maps guarded by one mutex. Soft-deleted rows
+// stay in the maps with DeletedAt set, as they would in Postgres.
+type Store struct {
+	mu         sync.Mutex
+	users      map[string]*models.User
+	watchlists map[string]*models.Watchlist
+	items      map[string]*models.WatchlistItem
+	likes      map[[2]string]models.Like // keyed by (user, watchlist)
+	shares     []models.Share
+	blocks     map[[2]string]models.Block // keyed by (blocker, blocked)
+	mutes      map[[2]string]models.Mute  // keyed by (muter, muted)
+	movies     map[int64]models.Movie
+}
+
+var _ storetest.Store = (*Store)(nil)
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{
+		users:      map[string]*models.User{},
+		watchlists: map[string]*models.Watchlist{},
+		items:      map[string]*models.WatchlistItem{},
+		likes:      map[[2]string]models.Like{},
+		blocks:     map[[2]string]models.Block{},
+		mutes:      map[[2]string]models.Mute{},
+		movies:     map[int64]models.Movie{},
+	}
+}
+
+var errNotFound = gorm.ErrRecordNotFound
+
+func now() time.Time { return time.Now().UTC() }
+
+func newID() string { return uuid.NewString() }
+
+// Users
+
+func (s *Store) UpsertUser(_ context.Context, u *models.User) error {
+	if u.ID == "" && u.Email == "" && u.Username == "" {
+		return errors.New("missing identifiers")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.users {
+		if existing.DeletedAt.Valid || existing.Email != u.Email {
+			continue
+		}
+		// Like gorm's Assign: copy over the non-zero fields.
+		if u.Username != "" {
+			existing.Username = u.Username
+		}
+		if u.Avatar != "" {
+			existing.Avatar = u.Avatar
+		}
+		existing.UpdatedAt = now()
+		*u = *existing
+		return nil
+	}
+	for _, other := range s.users {
+		if u.Username != "" && other.Username == u.Username {
+			return errors.New("duplicate username")
+		}
+	}
+	if u.ID == "" {
+		u.ID = newID()
+	}
+	u.CreatedAt, u.UpdatedAt = now(), now()
+	cp := *u
+	s.users[u.ID] = &cp
+	return nil
+}
+
+func (s *Store) GetUser(_ context.Context, id string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return nil, errNotFound
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (s *Store) userExists(id string) bool {
+	u, ok := s.users[id]
+	return ok && !u.DeletedAt.Valid
+}
+
+// Watchlists
+
+func (s *Store) CreateWatchlist(_ context.Context, wl *models.Watchlist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.userExists(wl.OwnerID) {
+		return errors.New("owner does not exist")
+	}
+	if wl.ID == "" {
+		wl.ID = newID()
+	}
+	if wl.ModerationStatus == "" {
+		wl.ModerationStatus = models.ModerationOK
+	}
+	wl.CreatedAt, wl.UpdatedAt = now(), now()
+	for i := range wl.Items {
+		it := &wl.Items[i]
+		it.WatchlistID = wl.ID
+		s.insertItem(it)
+	}
+	cp := *wl
+	cp.Items = nil
+	s.watchlists[wl.ID] = &cp
+	return nil
+}
+
+func (s *Store) UpdateWatchlist(_ context.Context, wl *models.Watchlist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing := s.live(wl.ID)
+	if existing == nil || existing.OwnerID != wl.OwnerID {
+		return nil
+	}
+	existing.Title = wl.Title
+	existing.Description = wl.Description
+	existing.IsPublic = wl.IsPublic
+	existing.ModerationStatus = wl.ModerationStatus
+	existing.UpdatedAt = now()
+	return nil
+}
+
+func (s *Store) DeleteWatchlist(_ context.Context, id, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wl := s.live(id)
+	if wl == nil || wl.OwnerID != owner {
+		return errNotFound
+	}
+	at := gorm.DeletedAt{Time: now(), Valid: true}
+	wl.DeletedAt = at
+	for _, it := range s.items {
+		if it.WatchlistID == id && !it.DeletedAt.Valid {
+			it.DeletedAt = at
+		}
+	}
+	return nil
+}
+
+// live returns the stored list unless it is missing or soft-deleted.
+func (s *Store) live(id string) *models.Watchlist {
+	wl, ok := s.watchlists[id]
+	if !ok || wl.DeletedAt.Valid {
+		return nil
+	}
+	return wl
+}
+
+func (s *Store) blocked(blocker, blocked string) bool {
+	_, ok := s.blocks[[2]string{blocker, blocked}]
+	return ok
+}
+
+func (s *Store) muted(muter, muted string) bool {
+	_, ok := s.mutes[[2]string{muter, muted}]
+	return ok
+}
+
+// reviewedOrOwned mirrors store's reviewedOrOwnedBy scope.
+func reviewedOrOwned(status, owner, viewer string) bool {
+	return status == models.ModerationOK || (viewer != "" && owner == viewer)
+}
+
+func (s *Store) GetWatchlist(_ context.Context, id, viewer string) (*models.Watchlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wl := s.live(id)
+	if wl == nil || (viewer != "" && s.blocked(wl.OwnerID, viewer)) || !reviewedOrOwned(wl.ModerationStatus, wl.OwnerID, viewer) {
+		return nil, errNotFound
+	}
+	cp := *wl
+	cp.Items = []models.WatchlistItem{}
+	for _, it := range s.items {
+		if it.WatchlistID == id && !it.DeletedAt.Valid && reviewedOrOwned(it.ModerationStatus, wl.OwnerID, viewer) {
+			cp.Items = append(cp.Items, s.withMovie(*it))
+		}
+	}
+	sort.SliceStable(cp.Items, func(i, j int) bool { return cp.Items[i].Position < cp.Items[j].Position })
+	return &cp, nil
+}
+
+func (s *Store) ListWatchlistsByOwner(_ context.Context, owner string) ([]models.Watchlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listWhere(func(wl *models.Watchlist) bool { return wl.OwnerID == owner }), nil
+}
+
+func (s *Store) ListPublicWatchlistsByOwner(_ context.Context, owner, viewer string) ([]models.Watchlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if viewer != "" && s.blocked(owner, viewer) {
+		return []models.Watchlist{}, nil
+	}
+	return s.listWhere(func(wl *models.Watchlist) bool {
+		return wl.OwnerID == owner && wl.IsPublic && reviewedOrOwned(wl.ModerationStatus, wl.OwnerID, viewer)
+	}), nil
+}
+
+// listWhere returns live lists matching keep, most recently updated first,
+// without items (the Postgres store does not preload them here either).
+func (s *Store) listWhere(keep func(*models.Watchlist) bool) []models.Watchlist {
+	out := []models.Watchlist{}
+	for _, wl := range s.watchlists {
+		if !wl.DeletedAt.Valid && keep(wl) {
+			out = append(out, *wl)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UpdatedAt.After(out[j].UpdatedAt) })
+	return out
+}
+
+func (s *Store) EnsureWatchlistOwner(_ context.Context, wlID, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ensureOwner(wlID, owner)
+}
+
+func (s *Store) ensureOwner(wlID, owner string) error {
+	if wl := s.live(wlID); wl == nil || wl.OwnerID != owner {
+		return errNotFound
+	}
+	return nil
+}
+
+// ensureVisible mirrors store's ensureWatchlistVisible.
+func (s *Store) ensureVisible(wlID, viewer string) error {
+	wl := s.live(wlID)
+	if wl == nil || (viewer != "" && s.blocked(wl.OwnerID, viewer)) || !(wl.IsPublic || wl.OwnerID == viewer) {
+		return errNotFound
+	}
+	return nil
+}
+
+// Items
+
+func (s *Store) AddItem(_ context.Context, it *models.WatchlistItem, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureOwner(it.WatchlistID, owner); err != nil {
+		return err
+	}
+	if it.MediaType == "" {
+		it.MediaType = models.MediaMovie
+	}
+	pos := 0
+	for _, other := range s.items {
+		if other.WatchlistID != it.WatchlistID || other.DeletedAt.Valid {
+			continue
+		}
+		if other.MediaType == it.MediaType && other.TMDBID == it.TMDBID {
+			return store.ErrDuplicateItem
+		}
+		if other.Position >= pos {
+			pos = other.Position + 1
+		}
+	}
+	it.Position = pos
+	s.insertItem(it)
+	return nil
+}
+
+func (s *Store) insertItem(it *models.WatchlistItem) {
+	if it.ID == "" {
+		it.ID = newID()
+	}
+	if it.ModerationStatus == "" {
+		it.ModerationStatus = models.ModerationOK
+	}
+	if it.MediaType == "" {
+		it.MediaType = models.MediaMovie
+	}
+	it.CreatedAt, it.UpdatedAt = now(), now()
+	cp := *it
+	s.items[it.ID] = &cp
+}
+
+func (s *Store) RemoveItem(_ context.Context, wlID, itemID, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureOwner(wlID, owner); err != nil {
+		return err
+	}
+	it, ok := s.items[itemID]
+	if !ok || it.WatchlistID != wlID || it.DeletedAt.Valid {
+		return errNotFound
+	}
+	it.DeletedAt = gorm.DeletedAt{Time: now(), Valid: true}
+	return nil
+}
+
+// Likes
+
+func (s *Store) Like(_ context.Context, user, wl string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureVisible(wl, user); err != nil {
+		return false, err
+	}
+	key := [2]string{user, wl}
+	if _, ok := s.likes[key]; ok {
+		return false, nil
+	}
+	s.likes[key] = models.Like{ID: newID(), CreatedAt: now(), UserID: user, WatchlistID: wl}
+	return true, nil
+}
+
+func (s *Store) Unlike(_ context.Context, user, wl string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.likes, [2]string{user, wl})
+	return nil
+}
+
+// LikeCount is a test helper: the number of likes on wl.
+func (s *Store) LikeCount(wl string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for key := range s.likes {
+		if key[1] == wl {
+			n++
+		}
+	}
+	return n
+}
+
+// Shares
+
+func (s *Store) ShareWatchlist(_ context.Context, sh *models.Share) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureVisible(sh.WatchlistID, sh.FromUserID); err != nil {
+		return err
+	}
+	if s.blocked(sh.ToUserID, sh.FromUserID) {
+		return store.ErrBlocked
+	}
+	if !s.userExists(sh.ToUserID) {
+		return errors.New("recipient does not exist")
+	}
+	if sh.ID == "" {
+		sh.ID = newID()
+	}
+	sh.CreatedAt = now()
+	s.shares = append(s.shares, *sh)
+	return nil
+}
+
+// Shares is a test helper returning every recorded share.
+func (s *Store) Shares() []models.Share {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Share(nil), s.shares...)
+}
+
+// Trending
+
+func (s *Store) TopWatchlists(_ context.Context, viewer, window string, limit int) ([]models.Watchlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var since time.Time
+	switch window {
+	case "week":
+		since = now().Add(-7 * 24 * time.Hour)
+	case "month":
+		since = now().Add(-30 * 24 * time.Hour)
+	}
+	counts := map[string]int{}
+	for _, l := range s.likes {
+		if l.CreatedAt.After(since) {
+			counts[l.WatchlistID]++
+		}
+	}
+	out := []models.Watchlist{}
+	for _, wl := range s.watchlists {
+		if wl.DeletedAt.Valid || !wl.IsPublic || wl.ModerationStatus != models.ModerationOK {
+			continue
+		}
+		if viewer != "" && (s.blocked(wl.OwnerID, viewer) || s.muted(viewer, wl.OwnerID)) {
+			continue
+		}
+		if !since.IsZero() && counts[wl.ID] == 0 {
+			continue
+		}
+		out = append(out, *wl)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if ci, cj := counts[out[i].ID], counts[out[j].ID]; ci != cj {
+			return ci > cj
+		}
+		return out[i].UpdatedAt.After(out[j].UpdatedAt)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// Blocks and mutes
+
+func (s *Store) Block(_ context.Context, blocker, blocked string) error {
+	if blocker == blocked {
+		return errors.New("cannot block yourself")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := [2]string{blocker, blocked}
+	if _, ok := s.blocks[key]; !ok {
+		s.blocks[key] = models.Block{ID: newID(), CreatedAt: now(), BlockerID: blocker, BlockedID: blocked}
+	}
+	for key, l := range s.likes {
+		if wl := s.live(l.WatchlistID); key[0] == blocked && wl != nil && wl.OwnerID == blocker {
+			delete(s.likes, key)
+		}
+	}
+	return nil
+}
+
+func (s *Store) Unblock(_ context.Context, blocker, blocked string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blocks, [2]string{blocker, blocked})
+	return nil
+}
+
+func (s *Store) IsBlocked(_ context.Context, blocker, blocked string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blocked(blocker, blocked), nil
+}
+
+func (s *Store) Mute(_ context.Context, muter, muted string) error {
+	if muter == muted {
+		return errors.New("cannot mute yourself")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := [2]string{muter, muted}
+	if _, ok := s.mutes[key]; !ok {
+		s.mutes[key] = models.Mute{ID: newID(), CreatedAt: now(), MuterID: muter, MutedID: muted}
+	}
+	return nil
+}
+
+func (s *Store) Unmute(_ context.Context, muter, muted string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.mutes, [2]string{muter, muted})
+	return nil
+}
+
+// Moderation
+
+func (s *Store) PendingReview(_ context.Context, limit int) (*store.ReviewQueue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := &store.ReviewQueue{Watchlists: []models.Watchlist{}, Items: []models.WatchlistItem{}}
+	for _, wl := range s.watchlists {
+		if !wl.DeletedAt.Valid && wl.ModerationStatus == models.ModerationPendingReview {
+			q.Watchlists = append(q.Watchlists, *wl)
+		}
+	}
+	for _, it := range s.items {
+		if !it.DeletedAt.Valid && it.ModerationStatus == models.ModerationPendingReview {
+			q.Items = append(q.Items, *it)
+		}
+	}
+	sort.SliceStable(q.Watchlists, func(i, j int) bool { return q.Watchlists[i].CreatedAt.Before(q.Watchlists[j].CreatedAt) })
+	sort.SliceStable(q.Items, func(i, j int) bool { return q.Items[i].CreatedAt.Before(q.Items[j].CreatedAt) })
+	if len(q.Watchlists) > limit {
+		q.Watchlists = q.Watchlists[:limit]
+	}
+	if len(q.Items) > limit {
+		q.Items = q.Items[:limit]
+	}
+	return q, nil
+}
+
+func (s *Store) ReviewWatchlist(_ context.Context, id, status string) error {
+	if err := store.CheckReview(status); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wl := s.live(id)
+	if wl == nil {
+		return errNotFound
+	}
+	wl.ModerationStatus, wl.UpdatedAt = status, now()
+	return nil
+}
+
+func (s *Store) ReviewItem(_ context.Context, id, status string) error {
+	if err := store.CheckReview(status); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[id]
+	if !ok || it.DeletedAt.Valid {
+		return errNotFound
+	}
+	it.ModerationStatus, it.UpdatedAt = status, now()
+	return nil
+}
+
+// Movies
+
+func (s *Store) Movie(_ context.Context, tmdbID int64) (*models.Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.movies[tmdbID]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &m, nil
+}
+
+func (s *Store) SaveMovie(_ context.Context, m *models.Movie) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m.UpdatedAt = now()
+	if old, ok := s.movies[m.TMDBID]; ok {
+		m.CreatedAt = old.CreatedAt
+	} else {
+		m.CreatedAt = m.UpdatedAt
+	}
+	s.movies[m.TMDBID] = *m
+	return nil
+}
+
+func (s *Store) StaleMovies(_ context.Context, cutoff time.Time, limit int) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var stale []models.Movie
+	for _, m := range s.movies {
+		if m.FetchedAt.Before(cutoff) {
+			stale = append(stale, m)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].FetchedAt.Before(stale[j].FetchedAt) })
+	ids := []int64{}
+	for _, m := range stale {
+		if limit > 0 && len(ids) == limit {
+			break
+		}
+		ids = append(ids, m.TMDBID)
+	}
+	return ids, nil
+}
+
+// withMovie mirrors store's withMovieMetadata: cached movie fields win
+// over the copies on the item unless they are empty.
+func (s *Store) withMovie(it models.WatchlistItem) models.WatchlistItem {
+	m, ok := s.movies[it.TMDBID]
+	if !ok || it.MediaType != models.MediaMovie {
+		return it
+	}
+	if m.Title != "" {
+		it.Title = m.Title
+	}
+	if m.PosterPath != "" {
+		it.PosterPath = m.PosterPath
+	}
+	if m.ReleaseDate != "" {
+		it.ReleaseDate = m.ReleaseDate
+	}
+	return it
+}
@@ -0,0 +1,14 @@
+package memstore_test
+
+import (
+	"testing"
+
+	"github.com/yourname/moodle/internal/store/memstore"
+	"github.com/yourname/moodle/internal/store/storetest"
+)
+
+func TestMemStore(t *testing.T) {
+	mem := func(t *testing.T) storetest.Store { return memstore.New() }
+	storetest.Run(t, mem)
+	storetest.RunConcurrent(t, mem)
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yourname/moodle/internal/models"
+)
+
+// Movie returns the cached TMDb details for a movie, or
+// gorm.ErrRecordNotFound when it has never been fetched.
+func (s *Store) Movie(ctx context.Context, tmdbID int64) (*models.Movie, error) {
+	var m models.Movie
+	if err := s.DB.WithContext(ctx).First(&m, "tmdb_id = ?", tmdbID).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SaveMovie inserts or replaces the cached details for m.TMDBID.
+func (s *Store) SaveMovie(ctx context.Context, m *models.Movie) error {
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tmdb_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "title", "poster_path", "release_date", "details", "fetched_at"}),
+	}).Create(m).Error
+}
+
+// StaleMovies lists up to limit movies fetched before cutoff, oldest first.
+func (s *Store) StaleMovies(ctx context.Context, cutoff time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	err := s.DB.WithContext(ctx).Model(&models.Movie{}).Where("fetched_at < ?", cutoff).
+		Order("fetched_at ASC").Limit(limit).Pluck("tmdb_id", &ids).Error
+	return ids, err
+}
+
+// withMovieMetadata selects watchlist items with the title, poster and
+// release date of their cached movie in place of the copies made when the
+// item was added. TV items keep their copies.
+func withMovieMetadata(tx *gorm.DB) *gorm.DB {
+	return tx.Select(`watchlist_items.id, watchlist_items.created_at, watchlist_items.updated_at, watchlist_items.deleted_at,
+		watchlist_items.watchlist_id, watchlist_items.media_type, watchlist_items.tmdb_id,
+		COALESCE(NULLIF(m.title, ''), watchlist_items.title) AS title,
+		COALESCE(NULLIF(m.poster_path, ''), watchlist_items.poster_path) AS poster_path,
+		COALESCE(NULLIF(m.release_date, ''), watchlist_items.release_date) AS release_date,
+		watchlist_items.notes, watchlist_items.position, watchlist_items.moderation_status`).
+		Joins("LEFT JOIN movies m ON m.tmdb_id = watchlist_items.tmdb_id AND watchlist_items.media_type = ?", models.MediaMovie)
+}
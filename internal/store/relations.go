@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yourname/moodle/internal/models"
+)
+
+// notBlockedBy drops rows whose owner (ownerCol) has blocked viewer.
+func notBlockedBy(ownerCol, viewer string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if viewer == "" {
+			return tx
+		}
+		return tx.Where("NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = "+ownerCol+" AND b.blocked_id = ?)", viewer)
+	}
+}
+
+// notMutedBy drops rows whose owner (ownerCol) viewer has muted.
+func notMutedBy(ownerCol, viewer string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if viewer == "" {
+			return tx
+		}
+		return tx.Where("NOT EXISTS (SELECT 1 FROM mutes m WHERE m.muter_id = ? AND m.muted_id = "+ownerCol+")", viewer)
+	}
+}
+
+// Blocks
+// Block makes blocker's lists invisible to blocked and drops any likes
+// blocked had left on them.
+func (s *Store) Block(ctx context.Context, blocker, blocked string) error {
+	if blocker == blocked {
+		return errors.New("cannot block yourself")
+	}
+	return s.WithTx(ctx, func(tx *Store) error {
+		db := tx.DB.WithContext(ctx)
+		if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "blocker_id"}, {Name: "blocked_id"}}, DoNothing: true}).Create(&models.Block{BlockerID: blocker, BlockedID: blocked}).Error; err != nil {
+			return err
+		}
+		return db.Where("user_id = ? AND watchlist_id IN (?)", blocked, db.Model(&models.Watchlist{}).Select("id").Where("owner_id = ?", blocker)).Delete(&models.Like{}).Error
+	})
+}
+
+func (s *Store) Unblock(ctx context.Context, blocker, blocked string) error {
+	return s.DB.WithContext(ctx).Where("blocker_id = ? AND blocked_id = ?", blocker, blocked).Delete(&models.Block{}).Error
+}
+
+// IsBlocked reports whether blocker has blocked blocked.
+func (s *Store) IsBlocked(ctx context.Context, blocker, blocked string) (bool, error) {
+	var count int64
+	if err := s.DB.WithContext(ctx).Model(&models.Block{}).Where("blocker_id = ? AND blocked_id = ?", blocker, blocked).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Mutes
+func (s *Store) Mute(ctx context.Context, muter, muted string) error {
+	if muter == muted {
+		return errors.New("cannot mute yourself")
+	}
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "muter_id"}, {Name: "muted_id"}}, DoNothing: true}).Create(&models.Mute{MuterID: muter, MutedID: muted}).Error
+}
+
+func (s *Store) Unmute(ctx context.Context, muter, muted string) error {
+	return s.DB.WithContext(ctx).Where("muter_id = ? AND muted_id = ?", muter, muted).Delete(&models.Mute{}).Error
+}
@@ -10,6 +10,13 @@ import (
 	"github.com/yourname/moodle/internal/models"
 )
 
+var (
+	// ErrBlocked is returned when an action crosses a block between two users.
+	ErrBlocked = errors.New("blocked")
+	// ErrDuplicateItem is returned when a title is already on the list.
+	ErrDuplicateItem = errors.New("already on this watchlist")
+)
+
 type Store struct{ DB *gorm.DB }
 
 func New(db *gorm.DB) *Store { return &Store{DB: db} }
@@ -37,17 +44,45 @@ func (s *Store) CreateWatchlist(ctx context.Context, wl *models.Watchlist) error
 
 func (s *Store) UpdateWatchlist(ctx context.Context, wl *models.Watchlist) error {
 	return s.DB.WithContext(ctx).Model(&models.Watchlist{}).Where("id = ? AND owner_id = ?", wl.ID, wl.OwnerID).Updates(map[string]any{
-		"title": wl.Title, "description": wl.Description, "is_public": wl.IsPublic,
+		"title": wl.Title, "description": wl.Description, "is_public": wl.IsPublic, "moderation_status": wl.ModerationStatus,
 	}).Error
 }
 
+// DeleteWatchlist soft-deletes the list and its items together.
 func (s *Store) DeleteWatchlist(ctx context.Context, id, owner string) error {
-	return s.DB.WithContext(ctx).Where("id = ? AND owner_id = ?", id, owner).Delete(&models.Watchlist{}).Error
+	return s.WithTx(ctx, func(tx *Store) error {
+		if err := tx.lockOwnedWatchlist(ctx, id, owner); err != nil {
+			return err
+		}
+		db := tx.DB.WithContext(ctx)
+		if err := db.Where("watchlist_id = ?", id).Delete(&models.WatchlistItem{}).Error; err != nil {
+			return err
+		}
+		return db.Where("id = ?", id).Delete(&models.Watchlist{}).Error
+	})
+}
+
+// reviewedOrOwnedBy hides content held for or rejected in moderation from
+// everyone but the owner of the list it belongs to.
+func reviewedOrOwnedBy(statusCol, ownerCol, viewer string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if viewer == "" {
+			return tx.Where(statusCol+" = ?", models.ModerationOK)
+		}
+		return tx.Where("("+statusCol+" = ? OR "+ownerCol+" = ?)", models.ModerationOK, viewer)
+	}
 }
 
-func (s *Store) GetWatchlist(ctx context.Context, id string) (*models.Watchlist, error) {
+// GetWatchlist loads a watchlist with its items, using the movies table's
+// metadata for them where it has any. Lists whose owner has blocked
+// viewer, or that are held for review, are reported as not found.
+func (s *Store) GetWatchlist(ctx context.Context, id, viewer string) (*models.Watchlist, error) {
 	var wl models.Watchlist
-	if err := s.DB.WithContext(ctx).Preload("Items", func(tx *gorm.DB) *gorm.DB { return tx.Order("position ASC") }).First(&wl, "id = ?", id).Error; err != nil {
+	items := func(tx *gorm.DB) *gorm.DB {
+		ownerOf := "(SELECT owner_id FROM watchlists WHERE watchlists.id = watchlist_items.watchlist_id)"
+		return tx.Scopes(withMovieMetadata, reviewedOrOwnedBy("watchlist_items.moderation_status", ownerOf, viewer)).Order("watchlist_items.position ASC")
+	}
+	if err := s.DB.WithContext(ctx).Scopes(notBlockedBy("watchlists.owner_id", viewer), reviewedOrOwnedBy("watchlists.moderation_status", "watchlists.owner_id", viewer)).Preload("Items", items).First(&wl, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &wl, nil
@@ -61,9 +96,12 @@ func (s *Store) ListWatchlistsByOwner(ctx context.Context, owner string) ([]mode
 	return out, nil
 }
 
-func (s *Store) ListPublicWatchlistsByOwner(ctx context.Context, owner string) ([]models.Watchlist, error) {
+// ListPublicWatchlistsByOwner returns owner's public lists as seen by viewer:
+// empty when owner has blocked viewer. Muting does not apply here; viewer
+// asked for owner's lists by name.
+func (s *Store) ListPublicWatchlistsByOwner(ctx context.Context, owner, viewer string) ([]models.Watchlist, error) {
 	var out []models.Watchlist
-	if err := s.DB.WithContext(ctx).Where("owner_id = ? AND is_public = TRUE", owner).Order("updated_at DESC").Find(&out).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Scopes(notBlockedBy("owner_id", viewer), reviewedOrOwnedBy("moderation_status", "owner_id", viewer)).Where("owner_id = ? AND is_public = TRUE", owner).Order("updated_at DESC").Find(&out).Error; err != nil {
 		return nil, err
 	}
 	return out, nil
@@ -80,39 +118,109 @@ func (s *Store) EnsureWatchlistOwner(ctx context.Context, wlID, owner string) er
 	return nil
 }
 
-// Items
-func (s *Store) AddItem(ctx context.Context, it *models.WatchlistItem, owner string) error {
-	if err := s.EnsureWatchlistOwner(ctx, it.WatchlistID, owner); err != nil {
+// ensureWatchlistVisible reports gorm.ErrRecordNotFound unless viewer owns the
+// list or it is public and its owner has not blocked viewer.
+func (s *Store) ensureWatchlistVisible(ctx context.Context, wlID, viewer string) error {
+	var count int64
+	if err := s.DB.WithContext(ctx).Model(&models.Watchlist{}).Scopes(notBlockedBy("watchlists.owner_id", viewer)).
+		Where("id = ? AND (is_public = TRUE OR owner_id = ?)", wlID, viewer).Count(&count).Error; err != nil {
 		return err
 	}
-	var pos int
-	if err := s.DB.WithContext(ctx).Model(&models.WatchlistItem{}).Where("watchlist_id = ?", it.WatchlistID).Select("COALESCE(MAX(position), -1)+1").Scan(&pos).Error; err != nil {
-		return err
+	if count == 0 {
+		return gorm.ErrRecordNotFound
 	}
-	it.Position = pos
-	return s.DB.WithContext(ctx).Create(it).Error
+	return nil
+}
+
+// Items
+// AddItem appends it to the end of the list, or returns ErrDuplicateItem
+// when the list already has the same title. The list row stays locked
+// until the insert commits, so concurrent adds get distinct positions and
+// cannot both add one title.
+func (s *Store) AddItem(ctx context.Context, it *models.WatchlistItem, owner string) error {
+	return s.WithTx(ctx, func(tx *Store) error {
+		if err := tx.lockOwnedWatchlist(ctx, it.WatchlistID, owner); err != nil {
+			return err
+		}
+		if it.MediaType == "" {
+			it.MediaType = models.MediaMovie
+		}
+		db := tx.DB.WithContext(ctx)
+		var dup int64
+		if err := db.Model(&models.WatchlistItem{}).Where("watchlist_id = ? AND media_type = ? AND tmdb_id = ?", it.WatchlistID, it.MediaType, it.TMDBID).Count(&dup).Error; err != nil {
+			return err
+		}
+		if dup > 0 {
+			return ErrDuplicateItem
+		}
+		if err := db.Model(&models.WatchlistItem{}).Where("watchlist_id = ?", it.WatchlistID).Select("COALESCE(MAX(position), -1)+1").Scan(&it.Position).Error; err != nil {
+			return err
+		}
+		return db.Create(it).Error
+	})
 }
 
 func (s *Store) RemoveItem(ctx context.Context, wlID, itemID, owner string) error {
-	if err := s.EnsureWatchlistOwner(ctx, wlID, owner); err != nil {
-		return err
-	}
-	return s.DB.WithContext(ctx).Where("id = ? AND watchlist_id = ?", itemID, wlID).Delete(&models.WatchlistItem{}).Error
+	return s.WithTx(ctx, func(tx *Store) error {
+		if err := tx.lockOwnedWatchlist(ctx, wlID, owner); err != nil {
+			return err
+		}
+		res := tx.DB.WithContext(ctx).Where("id = ? AND watchlist_id = ?", itemID, wlID).Delete(&models.WatchlistItem{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
 }
 
 // Likes
-func (s *Store) Like(ctx context.Context, user, wl string) error {
-	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}, {Name: "watchlist_id"}}, DoNothing: true}).Create(&models.Like{UserID: user, WatchlistID: wl}).Error
+// Like records that user likes wl and reports whether that is new; liking
+// a list twice is a no-op.
+func (s *Store) Like(ctx context.Context, user, wl string) (bool, error) {
+	var created bool
+	err := s.WithTx(ctx, func(tx *Store) error {
+		if err := tx.ensureWatchlistVisible(ctx, wl, user); err != nil {
+			return err
+		}
+		res := tx.DB.WithContext(ctx).Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}, {Name: "watchlist_id"}}, DoNothing: true}).Create(&models.Like{UserID: user, WatchlistID: wl})
+		created = res.RowsAffected > 0
+		return res.Error
+	})
+	return created, err
 }
 
 func (s *Store) Unlike(ctx context.Context, user, wl string) error {
 	return s.DB.WithContext(ctx).Where("user_id = ? AND watchlist_id = ?", user, wl).Delete(&models.Like{}).Error
 }
 
+// Shares
+// ShareWatchlist records sh after checking the sender can see the list and
+// the recipient has not blocked the sender.
+func (s *Store) ShareWatchlist(ctx context.Context, sh *models.Share) error {
+	return s.WithTx(ctx, func(tx *Store) error {
+		if err := tx.ensureWatchlistVisible(ctx, sh.WatchlistID, sh.FromUserID); err != nil {
+			return err
+		}
+		blocked, err := tx.IsBlocked(ctx, sh.ToUserID, sh.FromUserID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return ErrBlocked
+		}
+		return tx.DB.WithContext(ctx).Create(sh).Error
+	})
+}
+
 // Trending: like counts in time window (week/month); only public watchlists.
-func (s *Store) TopWatchlists(ctx context.Context, window string, limit int) ([]models.Watchlist, error) {
+// Lists hidden from or muted by viewer are left out.
+func (s *Store) TopWatchlists(ctx context.Context, viewer, window string, limit int) ([]models.Watchlist, error) {
 	var out []models.Watchlist
-	q := s.DB.WithContext(ctx).Table("watchlists w").Select("w.*").Joins("LEFT JOIN likes l ON l.watchlist_id = w.id").Where("w.is_public = TRUE")
+	q := s.DB.WithContext(ctx).Table("watchlists w").Select("w.*").Joins("LEFT JOIN likes l ON l.watchlist_id = w.id").
+		Where("w.is_public = TRUE AND w.deleted_at IS NULL AND w.moderation_status = ?", models.ModerationOK).Scopes(notBlockedBy("w.owner_id", viewer), notMutedBy("w.owner_id", viewer))
 	switch window {
 	case "week":
 		q = q.Where("l.created_at >= NOW() - interval '7 days'")
@@ -0,0 +1,20 @@
+package store_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/yourname/moodle/internal/store"
+	"github.com/yourname/moodle/internal/store/pgtest"
+	"github.com/yourname/moodle/internal/store/storetest"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+// TestPostgresStore runs the conformance suite against Postgres; it is
+// skipped when pgtest cannot find or start one.
+func TestPostgresStore(t *testing.T) {
+	pgtest.Shared(t)
+	storetest.Run(t, func(t *testing.T) storetest.Store { return store.New(pgtest.DB(t)) })
+	storetest.RunConcurrent(t, func(t *testing.T) storetest.Store { return store.New(pgtest.Shared(t)) })
+}
@@ -0,0 +1,657 @@
+// Package storetest is a conformance suite for store implementations. Both
+// the Postgres store and memstore must pass it, which keeps the in-memory
+// fake honest for handler tests. Wire it up from a test file:
+//
+//	func TestMemStore(t *testing.T) {
+//		mem := func(t *testing.T) storetest.Store { return memstore.New() }
+//		storetest.Run(t, mem)
+//		storetest.RunConcurrent(t, mem)
+//	}
+//
+// and for Postgres, with pgtest:
+//
+//	func TestPostgresStore(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) storetest.Store { return store.New(pgtest.DB(t)) })
+//		storetest.RunConcurrent(t, func(t *testing.T) storetest.Store { return store.New(pgtest.Shared(t)) })
+//	}
+//
+// Every case creates its own users, so a factory may hand out the same
+// database to several cases; trending assertions only look at the lists
+// the case created.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/store"
+)
+
+// Store is the surface the suite exercises.
+type Store interface {
+	UpsertUser(ctx context.Context, u *models.User) error
+	GetUser(ctx context.Context, id string) (*models.User, error)
+
+	CreateWatchlist(ctx context.Context, wl *models.Watchlist) error
+	UpdateWatchlist(ctx context.Context, wl *models.Watchlist) error
+	DeleteWatchlist(ctx context.Context, id, owner string) error
+	GetWatchlist(ctx context.Context, id, viewer string) (*models.Watchlist, error)
+	ListWatchlistsByOwner(ctx context.Context, owner string) ([]models.Watchlist, error)
+	ListPublicWatchlistsByOwner(ctx context.Context, owner, viewer string) ([]models.Watchlist, error)
+
+	AddItem(ctx context.Context, it *models.WatchlistItem, owner string) error
+	RemoveItem(ctx context.Context, wlID, itemID, owner string) error
+
+	Like(ctx context.Context, user, wl string) (bool, error)
+	Unlike(ctx context.Context, user, wl string) error
+	ShareWatchlist(ctx context.Context, sh *models.Share) error
+	TopWatchlists(ctx context.Context, viewer, window string, limit int) ([]models.Watchlist, error)
+
+	Block(ctx context.Context, blocker, blocked string) error
+	Unblock(ctx context.Context, blocker, blocked string) error
+	IsBlocked(ctx context.Context, blocker, blocked string) (bool, error)
+	Mute(ctx context.Context, muter, muted string) error
+	Unmute(ctx context.Context, muter, muted string) error
+
+	PendingReview(ctx context.Context, limit int) (*store.ReviewQueue, error)
+	ReviewWatchlist(ctx context.Context, id, status string) error
+	ReviewItem(ctx context.Context, id, status string) error
+
+	Movie(ctx context.Context, tmdbID int64) (*models.Movie, error)
+	SaveMovie(ctx context.Context, m *models.Movie) error
+	StaleMovies(ctx context.Context, cutoff time.Time, limit int) ([]int64, error)
+}
+
+var _ Store = (*store.Store)(nil)
+
+type testCase struct {
+	name string
+	fn   func(t *testing.T, s Store)
+}
+
+// Run runs the single-session cases against stores from newStore.
+func Run(t *testing.T, newStore func(t *testing.T) Store) {
+	run(t, newStore, []testCase{
+		{"Users", testUsers},
+		{"WatchlistOwnership", testWatchlistOwnership},
+		{"SoftDelete", testSoftDelete},
+		{"Visibility", testVisibility},
+		{"ItemPositions", testItemPositions},
+		{"DuplicateItems", testDuplicateItems},
+		{"LikeUniqueness", testLikeUniqueness},
+		{"Moderation", testModeration},
+		{"Review", testReview},
+		{"Blocks", testBlocks},
+		{"Mutes", testMutes},
+		{"Shares", testShares},
+		{"TrendingWindows", testTrendingWindows},
+		{"Movies", testMovies},
+	})
+}
+
+// RunConcurrent runs the cases that call the store from several goroutines
+// at once. A store bound to one transaction cannot take part; give it one
+// backed by a connection pool.
+func RunConcurrent(t *testing.T, newStore func(t *testing.T) Store) {
+	run(t, newStore, []testCase{
+		{"AddItem", testConcurrentAddItem},
+	})
+}
+
+func run(t *testing.T, newStore func(t *testing.T) Store, cases []testCase) {
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.fn(t, newStore(t))
+		})
+	}
+}
+
+var ctx = context.Background()
+
+func user(t *testing.T, s Store) string {
+	t.Helper()
+	name := "storetest-" + uuid.NewString()[:8]
+	u := &models.User{Email: name + "@example.test", Username: name}
+	if err := s.UpsertUser(ctx, u); err != nil {
+		t.Fatalf("UpsertUser: %v", err)
+	}
+	return u.ID
+}
+
+func watchlist(t *testing.T, s Store, owner string, public bool) *models.Watchlist {
+	t.Helper()
+	wl := &models.Watchlist{OwnerID: owner, Title: "list " + uuid.NewString()[:8], IsPublic: public, ModerationStatus: models.ModerationOK}
+	if err := s.CreateWatchlist(ctx, wl); err != nil {
+		t.Fatalf("CreateWatchlist: %v", err)
+	}
+	return wl
+}
+
+func item(t *testing.T, s Store, wl *models.Watchlist, tmdbID int64) *models.WatchlistItem {
+	t.Helper()
+	it := &models.WatchlistItem{WatchlistID: wl.ID, TMDBID: tmdbID, Title: fmt.Sprint("movie ", tmdbID), ModerationStatus: models.ModerationOK}
+	if err := s.AddItem(ctx, it, wl.OwnerID); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	return it
+}
+
+// like is Store.Like without the created flag.
+func like(s Store, user, wl string) error {
+	_, err := s.Like(ctx, user, wl)
+	return err
+}
+
+func wantNotFound(t *testing.T, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("%s: got %v, want gorm.ErrRecordNotFound", what, err)
+	}
+}
+
+func must(t *testing.T, what string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+}
+
+func ids(lists []models.Watchlist) map[string]bool {
+	out := map[string]bool{}
+	for _, wl := range lists {
+		out[wl.ID] = true
+	}
+	return out
+}
+
+// only keeps the IDs in lists that are in mine, preserving order.
+func only(lists []models.Watchlist, mine ...*models.Watchlist) []string {
+	keep := map[string]bool{}
+	for _, wl := range mine {
+		keep[wl.ID] = true
+	}
+	var out []string
+	for _, wl := range lists {
+		if keep[wl.ID] {
+			out = append(out, wl.ID)
+		}
+	}
+	return out
+}
+
+func testUsers(t *testing.T, s Store) {
+	id := user(t, s)
+	u, err := s.GetUser(ctx, id)
+	must(t, "GetUser", err)
+
+	again := &models.User{Email: u.Email, Avatar: "https://example.test/a.png"}
+	must(t, "UpsertUser existing", s.UpsertUser(ctx, again))
+	if again.ID != id {
+		t.Fatalf("upsert by email created %s, want existing %s", again.ID, id)
+	}
+	u, err = s.GetUser(ctx, id)
+	must(t, "GetUser", err)
+	if u.Avatar != "https://example.test/a.png" || u.Username == "" {
+		t.Fatalf("upsert should update avatar and keep username, got %+v", u)
+	}
+
+	_, err = s.GetUser(ctx, uuid.NewString())
+	wantNotFound(t, "GetUser missing", err)
+	if err := s.UpsertUser(ctx, &models.User{}); err == nil {
+		t.Fatal("UpsertUser without identifiers should fail")
+	}
+}
+
+func testWatchlistOwnership(t *testing.T, s Store) {
+	owner, other := user(t, s), user(t, s)
+	wl := watchlist(t, s, owner, false)
+	if wl.ID == "" || wl.IsPublic {
+		t.Fatalf("created list %+v, want an ID and is_public=false", wl)
+	}
+
+	got, err := s.GetWatchlist(ctx, wl.ID, owner)
+	must(t, "GetWatchlist", err)
+	if got.IsPublic {
+		t.Fatal("private list came back public")
+	}
+
+	hijack := *got
+	hijack.OwnerID, hijack.Title = other, "hijacked"
+	must(t, "UpdateWatchlist by other", s.UpdateWatchlist(ctx, &hijack))
+	got, _ = s.GetWatchlist(ctx, wl.ID, owner)
+	if got.Title == "hijacked" || got.OwnerID != owner {
+		t.Fatal("a non-owner changed the list")
+	}
+
+	got.Title, got.IsPublic = "renamed", true
+	must(t, "UpdateWatchlist", s.UpdateWatchlist(ctx, got))
+	got, _ = s.GetWatchlist(ctx, wl.ID, owner)
+	if got.Title != "renamed" || !got.IsPublic {
+		t.Fatalf("update not applied: %+v", got)
+	}
+
+	wantNotFound(t, "DeleteWatchlist by other", s.DeleteWatchlist(ctx, wl.ID, other))
+	wantNotFound(t, "AddItem by other", s.AddItem(ctx, &models.WatchlistItem{WatchlistID: wl.ID, TMDBID: 1, Title: "x"}, other))
+	it := item(t, s, wl, 1)
+	wantNotFound(t, "RemoveItem by other", s.RemoveItem(ctx, wl.ID, it.ID, other))
+
+	mine, err := s.ListWatchlistsByOwner(ctx, owner)
+	must(t, "ListWatchlistsByOwner", err)
+	if len(mine) != 1 || mine[0].ID != wl.ID {
+		t.Fatalf("ListWatchlistsByOwner = %v, want just %s", ids(mine), wl.ID)
+	}
+}
+
+func testSoftDelete(t *testing.T, s Store) {
+	owner := user(t, s)
+	keep, gone := watchlist(t, s, owner, true), watchlist(t, s, owner, true)
+	must(t, "DeleteWatchlist", s.DeleteWatchlist(ctx, gone.ID, owner))
+
+	_, err := s.GetWatchlist(ctx, gone.ID, owner)
+	wantNotFound(t, "GetWatchlist deleted", err)
+	wantNotFound(t, "DeleteWatchlist twice", s.DeleteWatchlist(ctx, gone.ID, owner))
+	wantNotFound(t, "AddItem to deleted", s.AddItem(ctx, &models.WatchlistItem{WatchlistID: gone.ID, TMDBID: 1, Title: "x"}, owner))
+	wantNotFound(t, "Like deleted", like(s, user(t, s), gone.ID))
+
+	mine, err := s.ListWatchlistsByOwner(ctx, owner)
+	must(t, "ListWatchlistsByOwner", err)
+	if got := ids(mine); len(got) != 1 || !got[keep.ID] {
+		t.Fatalf("ListWatchlistsByOwner = %v, want just %s", got, keep.ID)
+	}
+
+	it := item(t, s, keep, 7)
+	must(t, "RemoveItem", s.RemoveItem(ctx, keep.ID, it.ID, owner))
+	wantNotFound(t, "RemoveItem twice", s.RemoveItem(ctx, keep.ID, it.ID, owner))
+	got, err := s.GetWatchlist(ctx, keep.ID, owner)
+	must(t, "GetWatchlist", err)
+	if len(got.Items) != 0 {
+		t.Fatalf("removed item still listed: %+v", got.Items)
+	}
+}
+
+func testVisibility(t *testing.T, s Store) {
+	owner, viewer := user(t, s), user(t, s)
+	private, public := watchlist(t, s, owner, false), watchlist(t, s, owner, true)
+
+	wantNotFound(t, "Like private", like(s, viewer, private.ID))
+	wantNotFound(t, "Share private", s.ShareWatchlist(ctx, &models.Share{FromUserID: viewer, ToUserID: owner, WatchlistID: private.ID}))
+	must(t, "Like public", like(s, viewer, public.ID))
+	must(t, "Like own private", like(s, owner, private.ID))
+
+	lists, err := s.ListPublicWatchlistsByOwner(ctx, owner, viewer)
+	must(t, "ListPublicWatchlistsByOwner", err)
+	if got := ids(lists); len(got) != 1 || !got[public.ID] {
+		t.Fatalf("ListPublicWatchlistsByOwner = %v, want just %s", got, public.ID)
+	}
+	lists, err = s.ListPublicWatchlistsByOwner(ctx, owner, "")
+	must(t, "ListPublicWatchlistsByOwner anonymous", err)
+	if got := ids(lists); len(got) != 1 || !got[public.ID] {
+		t.Fatalf("anonymous ListPublicWatchlistsByOwner = %v, want just %s", got, public.ID)
+	}
+}
+
+func testItemPositions(t *testing.T, s Store) {
+	owner := user(t, s)
+	wl := watchlist(t, s, owner, true)
+	a, b, c := item(t, s, wl, 1), item(t, s, wl, 2), item(t, s, wl, 3)
+	if a.Position != 0 || b.Position != 1 || c.Position != 2 {
+		t.Fatalf("positions = %d,%d,%d, want 0,1,2", a.Position, b.Position, c.Position)
+	}
+	must(t, "RemoveItem", s.RemoveItem(ctx, wl.ID, c.ID, owner))
+	d := item(t, s, wl, 4)
+	if d.Position != 2 {
+		t.Fatalf("position after removing the last item = %d, want 2", d.Position)
+	}
+	got, err := s.GetWatchlist(ctx, wl.ID, owner)
+	must(t, "GetWatchlist", err)
+	var order []int64
+	for _, it := range got.Items {
+		order = append(order, it.TMDBID)
+	}
+	if fmt.Sprint(order) != "[1 2 4]" {
+		t.Fatalf("items in order %v, want [1 2 4]", order)
+	}
+}
+
+func testDuplicateItems(t *testing.T, s Store) {
+	owner := user(t, s)
+	wl := watchlist(t, s, owner, false)
+	movie := item(t, s, wl, 1399)
+	if movie.MediaType != models.MediaMovie {
+		t.Fatalf("media type defaulted to %q, want %q", movie.MediaType, models.MediaMovie)
+	}
+	err := s.AddItem(ctx, &models.WatchlistItem{WatchlistID: wl.ID, TMDBID: 1399, Title: "again"}, owner)
+	if !errors.Is(err, store.ErrDuplicateItem) {
+		t.Fatalf("adding a movie twice: got %v, want store.ErrDuplicateItem", err)
+	}
+	// The show with the same TMDb ID is a different title.
+	show := &models.WatchlistItem{WatchlistID: wl.ID, MediaType: models.MediaTV, TMDBID: 1399, Title: "show"}
+	must(t, "AddItem show", s.AddItem(ctx, show, owner))
+
+	must(t, "RemoveItem", s.RemoveItem(ctx, wl.ID, movie.ID, owner))
+	must(t, "AddItem after removal", s.AddItem(ctx, &models.WatchlistItem{WatchlistID: wl.ID, TMDBID: 1399, Title: "back"}, owner))
+	got, err := s.GetWatchlist(ctx, wl.ID, owner)
+	must(t, "GetWatchlist", err)
+	if len(got.Items) != 2 || got.Items[0].MediaType != models.MediaTV || got.Items[1].MediaType != models.MediaMovie {
+		t.Fatalf("items = %+v, want the show then the re-added movie", got.Items)
+	}
+}
+
+func testConcurrentAddItem(t *testing.T, s Store) {
+	owner := user(t, s)
+	wl := watchlist(t, s, owner, true)
+	const n = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			errs <- s.AddItem(ctx, &models.WatchlistItem{WatchlistID: wl.ID, TMDBID: id, Title: fmt.Sprint("movie ", id)}, owner)
+		}(int64(i + 1))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		must(t, "concurrent AddItem", err)
+	}
+	got, err := s.GetWatchlist(ctx, wl.ID, owner)
+	must(t, "GetWatchlist", err)
+	seen := map[int]bool{}
+	for _, it := range got.Items {
+		if seen[it.Position] || it.Position < 0 || it.Position >= n {
+			t.Fatalf("positions after concurrent adds are not 0..%d: %+v", n-1, got.Items)
+		}
+		seen[it.Position] = true
+	}
+	if len(seen) != n {
+		t.Fatalf("got %d items, want %d", len(seen), n)
+	}
+}
+
+func testLikeUniqueness(t *testing.T, s Store) {
+	owner, fan, other := user(t, s), user(t, s), user(t, s)
+	once, twice := watchlist(t, s, owner, true), watchlist(t, s, owner, true)
+
+	// twice gets the same like three times; once gets two distinct likes.
+	for i := 0; i < 3; i++ {
+		created, err := s.Like(ctx, fan, twice.ID)
+		must(t, "Like repeated", err)
+		if created != (i == 0) {
+			t.Fatalf("Like %d reported created=%v, want only the first", i+1, created)
+		}
+	}
+	must(t, "Like", like(s, fan, once.ID))
+	must(t, "Like", like(s, other, once.ID))
+	top, err := s.TopWatchlists(ctx, "", "week", 1000)
+	must(t, "TopWatchlists", err)
+	if got := only(top, once, twice); fmt.Sprint(got) != fmt.Sprint([]string{once.ID, twice.ID}) {
+		t.Fatalf("trending order %v, want %s (2 likes) before %s (1 like)", got, once.ID, twice.ID)
+	}
+
+	must(t, "Unlike", s.Unlike(ctx, fan, twice.ID))
+	must(t, "Unlike twice", s.Unlike(ctx, fan, twice.ID))
+	top, err = s.TopWatchlists(ctx, "", "week", 1000)
+	must(t, "TopWatchlists", err)
+	if ids(top)[twice.ID] {
+		t.Fatal("list with no likes left still trending this week")
+	}
+}
+
+func testModeration(t *testing.T, s Store) {
+	owner, viewer := user(t, s), user(t, s)
+	held := &models.Watchlist{OwnerID: owner, Title: "held", IsPublic: true, ModerationStatus: models.ModerationPendingReview}
+	must(t, "CreateWatchlist", s.CreateWatchlist(ctx, held))
+	must(t, "Like", like(s, owner, held.ID))
+
+	_, err := s.GetWatchlist(ctx, held.ID, viewer)
+	wantNotFound(t, "GetWatchlist held as viewer", err)
+	_, err = s.GetWatchlist(ctx, held.ID, "")
+	wantNotFound(t, "GetWatchlist held anonymously", err)
+	_, err = s.GetWatchlist(ctx, held.ID, owner)
+	must(t, "GetWatchlist held as owner", err)
+	lists, err := s.ListPublicWatchlistsByOwner(ctx, owner, viewer)
+	must(t, "ListPublicWatchlistsByOwner", err)
+	if ids(lists)[held.ID] {
+		t.Fatal("held list listed for another user")
+	}
+	top, err := s.TopWatchlists(ctx, owner, "", 1000)
+	must(t, "TopWatchlists", err)
+	if ids(top)[held.ID] {
+		t.Fatal("held list is trending")
+	}
+
+	wl := watchlist(t, s, owner, true)
+	item(t, s, wl, 1)
+	heldItem := &models.WatchlistItem{WatchlistID: wl.ID, TMDBID: 2, Title: "held", ModerationStatus: models.ModerationPendingReview}
+	must(t, "AddItem held", s.AddItem(ctx, heldItem, owner))
+	asViewer, err := s.GetWatchlist(ctx, wl.ID, viewer)
+	must(t, "GetWatchlist as viewer", err)
+	asOwner, err := s.GetWatchlist(ctx, wl.ID, owner)
+	must(t, "GetWatchlist as owner", err)
+	if len(asViewer.Items) != 1 || len(asOwner.Items) != 2 {
+		t.Fatalf("viewer sees %d items, owner %d; want 1 and 2", len(asViewer.Items), len(asOwner.Items))
+	}
+}
+
+// queued reports whether the review queue holds list wl and item it.
+func queued(t *testing.T, s Store, wl, it string) (bool, bool) {
+	t.Helper()
+	q, err := s.PendingReview(ctx, 10000)
+	must(t, "PendingReview", err)
+	var hasList, hasItem bool
+	for _, w := range q.Watchlists {
+		hasList = hasList || w.ID == wl
+	}
+	for _, i := range q.Items {
+		hasItem = hasItem || i.ID == it
+	}
+	return hasList, hasItem
+}
+
+func testReview(t *testing.T, s Store) {
+	owner, viewer := user(t, s), user(t, s)
+	held := &models.Watchlist{OwnerID: owner, Title: "held", IsPublic: true, ModerationStatus: models.ModerationPendingReview}
+	must(t, "CreateWatchlist", s.CreateWatchlist(ctx, held))
+	wl := watchlist(t, s, owner, true)
+	heldItem := &models.WatchlistItem{WatchlistID: wl.ID, TMDBID: 2, Title: "held", ModerationStatus: models.ModerationPendingReview}
+	must(t, "AddItem held", s.AddItem(ctx, heldItem, owner))
+	if l, i := queued(t, s, held.ID, heldItem.ID); !l || !i {
+		t.Fatalf("queue has list %v, item %v; want both", l, i)
+	}
+
+	if err := s.ReviewWatchlist(ctx, held.ID, models.ModerationPendingReview); err == nil {
+		t.Fatal("ReviewWatchlist accepted pending_review as a decision")
+	}
+	wantNotFound(t, "ReviewWatchlist missing", s.ReviewWatchlist(ctx, uuid.NewString(), models.ModerationOK))
+	wantNotFound(t, "ReviewItem missing", s.ReviewItem(ctx, uuid.NewString(), models.ModerationOK))
+
+	must(t, "ReviewWatchlist approve", s.ReviewWatchlist(ctx, held.ID, models.ModerationOK))
+	_, err := s.GetWatchlist(ctx, held.ID, viewer)
+	must(t, "GetWatchlist approved as viewer", err)
+	top, err := s.TopWatchlists(ctx, viewer, "", 1000)
+	must(t, "TopWatchlists", err)
+	if !ids(top)[held.ID] {
+		t.Fatal("approved list is not trending")
+	}
+
+	must(t, "ReviewItem reject", s.ReviewItem(ctx, heldItem.ID, models.ModerationRejected))
+	asViewer, err := s.GetWatchlist(ctx, wl.ID, viewer)
+	must(t, "GetWatchlist as viewer", err)
+	asOwner, err := s.GetWatchlist(ctx, wl.ID, owner)
+	must(t, "GetWatchlist as owner", err)
+	if len(asViewer.Items) != 0 || len(asOwner.Items) != 1 || asOwner.Items[0].ModerationStatus != models.ModerationRejected {
+		t.Fatalf("viewer sees %d items, owner %+v; want the rejected item for the owner only", len(asViewer.Items), asOwner.Items)
+	}
+	if l, i := queued(t, s, held.ID, heldItem.ID); l || i {
+		t.Fatalf("queue still has list %v, item %v after review", l, i)
+	}
+
+	must(t, "ReviewWatchlist reject", s.ReviewWatchlist(ctx, held.ID, models.ModerationRejected))
+	_, err = s.GetWatchlist(ctx, held.ID, viewer)
+	wantNotFound(t, "GetWatchlist rejected as viewer", err)
+	_, err = s.GetWatchlist(ctx, held.ID, owner)
+	must(t, "GetWatchlist rejected as owner", err)
+}
+
+func testBlocks(t *testing.T, s Store) {
+	owner, blocked := user(t, s), user(t, s)
+	wl := watchlist(t, s, owner, true)
+	must(t, "Like", like(s, blocked, wl.ID))
+
+	if err := s.Block(ctx, owner, owner); err == nil {
+		t.Fatal("blocking yourself should fail")
+	}
+	must(t, "Block", s.Block(ctx, owner, blocked))
+	must(t, "Block twice", s.Block(ctx, owner, blocked))
+	if ok, err := s.IsBlocked(ctx, owner, blocked); err != nil || !ok {
+		t.Fatalf("IsBlocked = %v, %v; want true", ok, err)
+	}
+	if ok, _ := s.IsBlocked(ctx, blocked, owner); ok {
+		t.Fatal("blocks are one-way")
+	}
+
+	_, err := s.GetWatchlist(ctx, wl.ID, blocked)
+	wantNotFound(t, "GetWatchlist as blocked", err)
+	wantNotFound(t, "Like as blocked", like(s, blocked, wl.ID))
+	lists, err := s.ListPublicWatchlistsByOwner(ctx, owner, blocked)
+	must(t, "ListPublicWatchlistsByOwner", err)
+	if len(lists) != 0 {
+		t.Fatalf("blocked user sees %d lists", len(lists))
+	}
+	top, err := s.TopWatchlists(ctx, "", "week", 1000)
+	must(t, "TopWatchlists", err)
+	if ids(top)[wl.ID] {
+		t.Fatal("blocking should drop the blocked user's like")
+	}
+	top, err = s.TopWatchlists(ctx, blocked, "", 1000)
+	must(t, "TopWatchlists as blocked", err)
+	if ids(top)[wl.ID] {
+		t.Fatal("blocked user sees the list in trending")
+	}
+
+	must(t, "Unblock", s.Unblock(ctx, owner, blocked))
+	_, err = s.GetWatchlist(ctx, wl.ID, blocked)
+	must(t, "GetWatchlist after unblock", err)
+}
+
+func testMutes(t *testing.T, s Store) {
+	owner, muter := user(t, s), user(t, s)
+	wl := watchlist(t, s, owner, true)
+	must(t, "Like", like(s, owner, wl.ID))
+
+	if err := s.Mute(ctx, muter, muter); err == nil {
+		t.Fatal("muting yourself should fail")
+	}
+	must(t, "Mute", s.Mute(ctx, muter, owner))
+	must(t, "Mute twice", s.Mute(ctx, muter, owner))
+
+	top, err := s.TopWatchlists(ctx, muter, "", 1000)
+	must(t, "TopWatchlists", err)
+	if ids(top)[wl.ID] {
+		t.Fatal("muted user's list is trending for the muter")
+	}
+	// Muting only filters trending; the owner's lists stay reachable.
+	_, err = s.GetWatchlist(ctx, wl.ID, muter)
+	must(t, "GetWatchlist as muter", err)
+	lists, err := s.ListPublicWatchlistsByOwner(ctx, owner, muter)
+	must(t, "ListPublicWatchlistsByOwner", err)
+	if len(lists) != 1 {
+		t.Fatalf("muter sees %d lists of the muted user, want 1", len(lists))
+	}
+
+	must(t, "Unmute", s.Unmute(ctx, muter, owner))
+	top, err = s.TopWatchlists(ctx, muter, "", 1000)
+	must(t, "TopWatchlists after unmute", err)
+	if !ids(top)[wl.ID] {
+		t.Fatal("list not trending for the muter after unmute")
+	}
+}
+
+func testShares(t *testing.T, s Store) {
+	owner, friend, stranger := user(t, s), user(t, s), user(t, s)
+	wl := watchlist(t, s, owner, true)
+	must(t, "ShareWatchlist", s.ShareWatchlist(ctx, &models.Share{FromUserID: owner, ToUserID: friend, WatchlistID: wl.ID}))
+	must(t, "ShareWatchlist by non-owner of public list", s.ShareWatchlist(ctx, &models.Share{FromUserID: friend, ToUserID: stranger, WatchlistID: wl.ID}))
+
+	must(t, "Block", s.Block(ctx, stranger, friend))
+	if err := s.ShareWatchlist(ctx, &models.Share{FromUserID: friend, ToUserID: stranger, WatchlistID: wl.ID}); !errors.Is(err, store.ErrBlocked) {
+		t.Fatalf("share to a user who blocked the sender: got %v, want store.ErrBlocked", err)
+	}
+}
+
+func testTrendingWindows(t *testing.T, s Store) {
+	owner, a, b := user(t, s), user(t, s), user(t, s)
+	hot, warm, cold, private := watchlist(t, s, owner, true), watchlist(t, s, owner, true), watchlist(t, s, owner, true), watchlist(t, s, owner, false)
+	must(t, "Like", like(s, a, hot.ID))
+	must(t, "Like", like(s, b, hot.ID))
+	must(t, "Like", like(s, a, warm.ID))
+	must(t, "Like", like(s, owner, private.ID))
+
+	for _, window := range []string{"week", "month"} {
+		top, err := s.TopWatchlists(ctx, "", window, 1000)
+		must(t, "TopWatchlists "+window, err)
+		if got := only(top, hot, warm, cold, private); fmt.Sprint(got) != fmt.Sprint([]string{hot.ID, warm.ID}) {
+			t.Fatalf("%s trending = %v, want [%s %s]", window, got, hot.ID, warm.ID)
+		}
+	}
+	top, err := s.TopWatchlists(ctx, "", "", 1000)
+	must(t, "TopWatchlists all time", err)
+	if got := ids(top); !got[cold.ID] || got[private.ID] {
+		t.Fatal("all-time trending should include public lists without likes and never private ones")
+	}
+	top, err = s.TopWatchlists(ctx, "", "week", 1)
+	must(t, "TopWatchlists limit", err)
+	if len(top) != 1 {
+		t.Fatalf("limit 1 returned %d lists", len(top))
+	}
+}
+
+func testMovies(t *testing.T, s Store) {
+	owner := user(t, s)
+	wl := watchlist(t, s, owner, false)
+	// IDs far above TMDb's keep the case clear of other cases' rows.
+	base := 9_000_000_000 + time.Now().UnixNano()%1_000_000*10
+	it := item(t, s, wl, base)
+	_, err := s.Movie(ctx, base)
+	wantNotFound(t, "Movie before SaveMovie", err)
+
+	epoch := time.Unix(0, 0)
+	m := &models.Movie{TMDBID: base, Title: "Retitled", PosterPath: "/new.jpg", Details: []byte(`{"id":1}`), FetchedAt: epoch}
+	must(t, "SaveMovie", s.SaveMovie(ctx, m))
+	got, err := s.GetWatchlist(ctx, wl.ID, owner)
+	must(t, "GetWatchlist", err)
+	if len(got.Items) != 1 || got.Items[0].ID != it.ID || got.Items[0].Title != "Retitled" || got.Items[0].PosterPath != "/new.jpg" {
+		t.Fatalf("items = %+v, want the cached title and poster", got.Items)
+	}
+
+	m.Title, m.FetchedAt = "Retitled again", epoch.Add(time.Hour)
+	must(t, "SaveMovie again", s.SaveMovie(ctx, m))
+	must(t, "SaveMovie second", s.SaveMovie(ctx, &models.Movie{TMDBID: base + 1, Details: []byte(`{}`), FetchedAt: epoch}))
+	must(t, "SaveMovie fresh", s.SaveMovie(ctx, &models.Movie{TMDBID: base + 2, Details: []byte(`{}`), FetchedAt: time.Now()}))
+	cached, err := s.Movie(ctx, base)
+	must(t, "Movie", err)
+	if cached.Title != "Retitled again" || !cached.FetchedAt.Equal(epoch.Add(time.Hour)) {
+		t.Fatalf("Movie = %+v after replacing it", cached)
+	}
+
+	stale, err := s.StaleMovies(ctx, epoch.Add(24*time.Hour), 1000)
+	must(t, "StaleMovies", err)
+	var mine []int64
+	for _, id := range stale {
+		if id >= base && id <= base+2 {
+			mine = append(mine, id)
+		}
+	}
+	if len(mine) != 2 || mine[0] != base+1 || mine[1] != base {
+		t.Fatalf("StaleMovies = %v, want [%d %d] oldest first", mine, base+1, base)
+	}
+}
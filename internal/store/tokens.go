@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yourname/moodle/internal/models"
+)
+
+// ErrInvalidToken is returned for API tokens that are unknown, revoked or expired.
+var ErrInvalidToken = errors.New("invalid api token")
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken issues a token for t.UserID and returns it in plain text;
+// it cannot be recovered afterwards.
+func (s *Store) CreateAPIToken(ctx context.Context, t *models.APIToken) (string, error) {
+	if _, err := s.GetUser(ctx, t.UserID); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := models.APITokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	t.Prefix = raw[:len(models.APITokenPrefix)+6]
+	t.Hash = hashToken(raw)
+	if err := s.DB.WithContext(ctx).Create(t).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func (s *Store) ListAPITokens(ctx context.Context, userID string) ([]models.APIToken, error) {
+	var out []models.APIToken
+	if err := s.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) RevokeAPIToken(ctx context.Context, id string) error {
+	res := s.DB.WithContext(ctx).Model(&models.APIToken{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UserForAPIToken returns the user a live token belongs to and stamps its
+// last use.
+func (s *Store) UserForAPIToken(ctx context.Context, raw string) (string, error) {
+	var t models.APIToken
+	err := s.DB.WithContext(ctx).
+		Where("hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())", hashToken(raw)).
+		First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrInvalidToken
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := s.DB.WithContext(ctx).Model(&t).Update("last_used_at", time.Now()).Error; err != nil {
+		return "", err
+	}
+	return t.UserID, nil
+}
@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yourname/moodle/internal/models"
+)
+
+// WithTx runs fn against a Store bound to one transaction, committing when
+// fn returns nil and rolling back otherwise. Calling WithTx on a Store
+// that is already in a transaction nests through a savepoint, so store
+// methods can use it internally and still compose inside a caller's
+// unit of work.
+func (s *Store) WithTx(ctx context.Context, fn func(tx *Store) error) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&Store{DB: tx})
+	})
+}
+
+// lockOwnedWatchlist takes a row lock on list wlID if owner owns it and
+// reports gorm.ErrRecordNotFound otherwise. Writers that derive state from
+// the list's items (such as the next position) serialise on this lock.
+func (s *Store) lockOwnedWatchlist(ctx context.Context, wlID, owner string) error {
+	var wl models.Watchlist
+	return s.DB.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id = ? AND owner_id = ?", wlID, owner).Take(&wl).Error
+}
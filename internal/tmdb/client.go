@@ -3,25 +3,143 @@ package tmdb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"time"
+
+	"github.com/yourname/moodle/internal/i18n"
 )
 
+// Client calls TMDb. Clients from New retry failed GETs (Retry), throttle
+// themselves to TMDb's request limits and stop calling for a while after
+// repeated failures. Every call asks for the language and region of the
+// i18n.Locale in its context.
 type Client struct {
 	APIKey  string
 	BaseURL string
 	HTTP    *http.Client
+	Retry   RetryPolicy
+
+	limiter *limiter
+	breaker *breaker
 }
 
+// Movie is a movie as listed in search, trending and discover results.
+// GetMovie fills in the detail fields too: runtime, genres, tagline and the
+// appended credits, videos and external IDs.
 type Movie struct {
-	ID           int64  `json:"id"`
-	Title        string `json:"title"`
-	Overview     string `json:"overview"`
-	PosterPath   string `json:"poster_path"`
-	BackdropPath string `json:"backdrop_path"`
-	ReleaseDate  string `json:"release_date"`
+	ID           int64   `json:"id"`
+	Title        string  `json:"title"`
+	Overview     string  `json:"overview"`
+	PosterPath   string  `json:"poster_path"`
+	BackdropPath string  `json:"backdrop_path"`
+	ReleaseDate  string  `json:"release_date"`
+	VoteAverage  float64 `json:"vote_average,omitempty"`
+	VoteCount    int     `json:"vote_count,omitempty"`
+
+	Runtime     int          `json:"runtime,omitempty"` // minutes
+	Genres      []Genre      `json:"genres,omitempty"`
+	Tagline     string       `json:"tagline,omitempty"`
+	Credits     *Credits     `json:"credits,omitempty"`
+	Videos      *Videos      `json:"videos,omitempty"`
+	ExternalIDs *ExternalIDs `json:"external_ids,omitempty"`
+}
+
+type Genre struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Credits is trimmed by GetMovie to the top TopCast billed actors and the
+// directors.
+type Credits struct {
+	Cast []CastMember `json:"cast"`
+	Crew []CrewMember `json:"crew"`
+}
+
+type CastMember struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Character   string `json:"character"`
+	ProfilePath string `json:"profile_path"`
+	Order       int    `json:"order"`
+}
+
+type CrewMember struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Job         string `json:"job"`
+	Department  string `json:"department"`
+	ProfilePath string `json:"profile_path"`
+}
+
+// Directors returns the crew credited as Director.
+func (c *Credits) Directors() []CrewMember {
+	if c == nil {
+		return nil
+	}
+	out := []CrewMember{}
+	for _, m := range c.Crew {
+		if m.Job == "Director" {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Videos is trimmed by GetMovie to YouTube trailers and teasers.
+type Videos struct {
+	Results []Video `json:"results"`
+}
+
+// Video is a clip hosted on Site; on YouTube, Key is the video ID.
+type Video struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Site        string `json:"site"`
+	Type        string `json:"type"`
+	Official    bool   `json:"official"`
+	PublishedAt string `json:"published_at"`
+}
+
+type ExternalIDs struct {
+	IMDbID      string `json:"imdb_id,omitempty"`
+	WikidataID  string `json:"wikidata_id,omitempty"`
+	FacebookID  string `json:"facebook_id,omitempty"`
+	InstagramID string `json:"instagram_id,omitempty"`
+	TwitterID   string `json:"twitter_id,omitempty"`
+}
+
+// TopCast is how many billed actors GetMovie keeps.
+const TopCast = 10
+
+// movieAppends are the sub-requests GetMovie folds into one call.
+const movieAppends = "credits,videos,external_ids"
+
+// trim drops the parts of the appended responses nobody shows: the long
+// tail of the cast, crew other than directors and videos other than
+// YouTube trailers and teasers.
+func (m *Movie) trim() {
+	if m.Credits != nil {
+		sort.SliceStable(m.Credits.Cast, func(i, j int) bool { return m.Credits.Cast[i].Order < m.Credits.Cast[j].Order })
+		if len(m.Credits.Cast) > TopCast {
+			m.Credits.Cast = m.Credits.Cast[:TopCast]
+		}
+		m.Credits.Crew = m.Credits.Directors()
+	}
+	if m.Videos != nil {
+		keep := []Video{}
+		for _, v := range m.Videos.Results {
+			if v.Site == "YouTube" && (v.Type == "Trailer" || v.Type == "Teaser") {
+				keep = append(keep, v)
+			}
+		}
+		m.Videos.Results = keep
+	}
 }
 
 type SearchMoviesResponse struct {
@@ -41,55 +159,57 @@ type DiscoverResponse struct {
 	Results []Movie `json:"results"`
 }
 
+// Defaults for New: TMDb allows roughly 50 requests a second per IP.
+const (
+	DefaultRequestsPerSecond = 40
+	DefaultBurst             = 20
+)
+
+var (
+	DefaultRetry   = RetryPolicy{Attempts: 3, BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}
+	DefaultBreaker = BreakerPolicy{Failures: 5, Cooldown: 30 * time.Second}
+)
+
 func New(apiKey, base string) *Client {
 	return &Client{
 		APIKey:  apiKey,
 		BaseURL: base,
 		HTTP:    &http.Client{Timeout: 10 * time.Second},
+		Retry:   DefaultRetry,
+		limiter: newLimiter(DefaultRequestsPerSecond, DefaultBurst),
+		breaker: &breaker{policy: DefaultBreaker},
 	}
 }
 
-func (c *Client) SearchMovies(ctx context.Context, query string, page int) (*SearchMoviesResponse, error) {
-	u, _ := url.Parse(c.BaseURL + "/search/movie")
-	q := u.Query()
-	q.Set("api_key", c.APIKey)
-	q.Set("query", query)
-	if page > 0 {
-		q.Set("page", fmt.Sprint(page))
-	}
-	u.RawQuery = q.Encode()
+// SetRateLimit throttles the client to perSecond requests with bursts of
+// up to burst; zero turns throttling off. Call it before first use.
+func (c *Client) SetRateLimit(perSecond float64, burst int) {
+	c.limiter = newLimiter(perSecond, burst)
+}
 
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	res, err := c.HTTP.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("tmdb status %d", res.StatusCode)
-	}
+// SetBreaker replaces the circuit breaker; zero Failures turns it off.
+// Call it before first use.
+func (c *Client) SetBreaker(p BreakerPolicy) {
+	c.breaker = &breaker{policy: p}
+}
+
+func (c *Client) SearchMovies(ctx context.Context, query string, page int) (*SearchMoviesResponse, error) {
 	var out SearchMoviesResponse
-	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+	if err := c.get(ctx, "/search/movie", searchQuery(query, page), &out); err != nil {
 		return nil, err
 	}
 	return &out, nil
 }
 
+// GetMovie gets a movie's details with its credits, videos and external
+// IDs appended, in one request.
 func (c *Client) GetMovie(ctx context.Context, id int64) (*Movie, error) {
-	u := fmt.Sprintf("%s/movie/%d?api_key=%s", c.BaseURL, id, url.QueryEscape(c.APIKey))
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-	res, err := c.HTTP.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("tmdb status %d", res.StatusCode)
-	}
 	var out Movie
-	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+	q := url.Values{"append_to_response": {movieAppends}}
+	if err := c.get(ctx, fmt.Sprintf("/movie/%d", id), q, &out); err != nil {
 		return nil, err
 	}
+	out.trim()
 	return &out, nil
 }
 
@@ -98,27 +218,15 @@ func (c *Client) TrendingMovies(ctx context.Context, window string, page int, re
 	if window == "" {
 		window = "day"
 	}
-	u, _ := url.Parse(c.BaseURL + "/trending/movie/" + window)
-	q := u.Query()
-	q.Set("api_key", c.APIKey)
+	q := url.Values{}
 	if page > 0 {
 		q.Set("page", fmt.Sprint(page))
 	}
 	if region != "" {
 		q.Set("region", region)
 	}
-	u.RawQuery = q.Encode()
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	res, err := c.HTTP.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("tmdb status %d", res.StatusCode)
-	}
 	var out TrendingResponse
-	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+	if err := c.get(ctx, "/trending/movie/"+window, q, &out); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -127,9 +235,7 @@ func (c *Client) TrendingMovies(ctx context.Context, window string, page int, re
 // DiscoverMovies provides a randomized-like feed using discover with sort_by.
 // Filters: with_genres, primary_release_year, region, sort_by (popularity.desc|vote_average.desc|release_date.desc)
 func (c *Client) DiscoverMovies(ctx context.Context, page int, genre, year, region, sortBy string) (*DiscoverResponse, error) {
-	u, _ := url.Parse(c.BaseURL + "/discover/movie")
-	q := u.Query()
-	q.Set("api_key", c.APIKey)
+	q := url.Values{}
 	if page > 0 {
 		q.Set("page", fmt.Sprint(page))
 	}
@@ -146,19 +252,127 @@ func (c *Client) DiscoverMovies(ctx context.Context, page int, genre, year, regi
 		sortBy = "popularity.desc"
 	}
 	q.Set("sort_by", sortBy)
-	u.RawQuery = q.Encode()
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	var out DiscoverResponse
+	if err := c.get(ctx, "/discover/movie", q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Ping checks that TMDb is reachable and the API key is accepted.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.get(ctx, "/configuration", nil, nil)
+}
+
+// get fetches path into out (when not nil) through the breaker, the rate
+// limiter and the retry policy, in the language and region of ctx.
+func (c *Client) get(ctx context.Context, path string, q url.Values, out any) error {
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	err := c.getWithRetries(ctx, path, localize(ctx, q), out)
+	c.breaker.record(breakerOutcome(err))
+	return err
+}
+
+// localize adds the language and region of the i18n.Locale in ctx to q.
+// A region the caller set wins, and English sends nothing, leaving TMDb's
+// en-US default (and the recorded fixtures) alone.
+func localize(ctx context.Context, q url.Values) url.Values {
+	loc := i18n.FromContext(ctx)
+	if loc.Language == "" && (loc.Region == "" || q.Has("region")) {
+		return q
+	}
+	out := url.Values{}
+	for k, v := range q {
+		out[k] = v
+	}
+	if loc.Language != "" {
+		out.Set("language", loc.Language)
+	}
+	if loc.Region != "" && !q.Has("region") {
+		out.Set("region", loc.Region)
+	}
+	return out
+}
+
+// breakerOutcome says whether err suggests TMDb itself is in trouble, as
+// opposed to a bad request or a caller that gave up, which prove nothing
+// either way.
+func breakerOutcome(err error) outcome {
+	if err == nil {
+		return succeeded
+	}
+	if errors.Is(err, context.Canceled) {
+		return neutral
+	}
+	var se *StatusError
+	if errors.As(err, &se) && se.Status < 500 {
+		return neutral
+	}
+	return failed
+}
+
+func (c *Client) getWithRetries(ctx context.Context, path string, q url.Values, out any) error {
+	attempts := max(c.Retry.Attempts, 1)
+	for n := 1; ; n++ {
+		if err := c.limiter.wait(ctx); err != nil {
+			return err
+		}
+		retry, err := c.getOnce(ctx, path, q, out)
+		if err == nil || !retry || n >= attempts || ctx.Err() != nil {
+			return err
+		}
+		wait := c.Retry.backoff(n)
+		if after, ok := RetryAfter(err); ok {
+			if c.Retry.MaxDelay > 0 && after > c.Retry.MaxDelay {
+				return err
+			}
+			wait = after
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// getOnce makes one request and reports whether a failure is worth retrying.
+func (c *Client) getOnce(ctx context.Context, path string, q url.Values, out any) (bool, error) {
+	withKey := url.Values{}
+	for k, v := range q {
+		withKey[k] = v
+	}
+	withKey.Set("api_key", c.APIKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path+"?"+withKey.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
 	res, err := c.HTTP.Do(req)
 	if err != nil {
-		return nil, err
+		// The URL carries the API key; keep it out of logs and health output.
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			err = ue.Err
+		}
+		return true, err
 	}
 	defer res.Body.Close()
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("tmdb status %d", res.StatusCode)
+		se := &StatusError{Status: res.StatusCode, RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now())}
+		var body struct {
+			StatusCode    int    `json:"status_code"`
+			StatusMessage string `json:"status_message"`
+		}
+		if json.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(&body) == nil {
+			se.Code, se.Message = body.StatusCode, body.StatusMessage
+		}
+		return retryable(res.StatusCode), se
 	}
-	var out DiscoverResponse
-	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
-		return nil, err
+	if out == nil {
+		return false, nil
 	}
-	return &out, nil
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("tmdb: decode %s: %w", path, err)
+	}
+	return false, nil
 }
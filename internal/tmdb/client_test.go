@@ -0,0 +1,198 @@
+package tmdb_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourname/moodle/internal/i18n"
+	"github.com/yourname/moodle/internal/tmdb"
+	"github.com/yourname/moodle/internal/tmdb/tmdbtest"
+)
+
+const movieQuery = "append_to_response=credits,videos,external_ids"
+
+func fake(t *testing.T) (*tmdbtest.Server, *tmdb.Client) {
+	t.Helper()
+	srv, err := tmdbtest.NewServer(tmdbtest.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	c := tmdb.New("test-key", srv.URL)
+	c.Retry = tmdb.RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	return srv, c
+}
+
+func reply(status int, body string) tmdbtest.Fixture {
+	return tmdbtest.Fixture{Path: "/movie/550", Query: movieQuery, Status: status, Body: json.RawMessage(body)}
+}
+
+func TestGetMovie(t *testing.T) {
+	_, c := fake(t)
+	m, err := c.GetMovie(context.Background(), 550)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Title != "Fight Club" || m.Runtime != 139 || m.ExternalIDs == nil || m.ExternalIDs.IMDbID != "tt0137523" {
+		t.Fatalf("GetMovie(550) = %+v", m)
+	}
+	if len(m.Credits.Directors()) != 1 {
+		t.Fatalf("directors = %+v, want one", m.Credits.Directors())
+	}
+}
+
+func TestGetMovieTrims(t *testing.T) {
+	srv, c := fake(t)
+	var cast []string
+	for i := 15; i > 0; i-- {
+		cast = append(cast, fmt.Sprintf(`{"id":%d,"name":"Actor %d","order":%d}`, i, i, i-1))
+	}
+	srv.Set(reply(http.StatusOK, `{"id":550,"title":"Fight Club",
+		"credits":{"cast":[`+strings.Join(cast, ",")+`],"crew":[{"id":1,"name":"David Fincher","job":"Director"},{"id":2,"name":"Jim Uhls","job":"Screenplay"}]},
+		"videos":{"results":[{"key":"a","site":"YouTube","type":"Trailer"},{"key":"b","site":"YouTube","type":"Featurette"},{"key":"c","site":"Vimeo","type":"Trailer"}]}}`))
+
+	m, err := c.GetMovie(context.Background(), 550)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.Credits.Cast); n != tmdb.TopCast || m.Credits.Cast[0].Order != 0 {
+		t.Fatalf("cast = %d members starting at order %d, want %d by billing", n, m.Credits.Cast[0].Order, tmdb.TopCast)
+	}
+	if len(m.Credits.Crew) != 1 || m.Credits.Crew[0].Job != "Director" {
+		t.Fatalf("crew = %+v, want the director only", m.Credits.Crew)
+	}
+	if len(m.Videos.Results) != 1 || m.Videos.Results[0].Key != "a" {
+		t.Fatalf("videos = %+v, want the YouTube trailer only", m.Videos.Results)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	srv, c := fake(t)
+	srv.Set(reply(http.StatusOK, `{"id":"not a number"}`))
+	_, err := c.GetMovie(context.Background(), 550)
+	if err == nil || !strings.Contains(err.Error(), "tmdb: decode /movie/550") {
+		t.Fatalf("err = %v, want a decode error", err)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("%d requests, want no retries of a bad body", n)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	cases := []struct {
+		name     string
+		replies  []tmdbtest.Fixture
+		want     error
+		requests int
+	}{
+		{"recovers from 503", []tmdbtest.Fixture{reply(503, `{}`), reply(502, `{}`)}, nil, 3},
+		{"gives up after attempts", []tmdbtest.Fixture{reply(500, `{}`), reply(500, `{}`), reply(500, `{}`)}, &tmdb.StatusError{}, 3},
+		{"waits out a short Retry-After", []tmdbtest.Fixture{{Path: "/movie/550", Query: movieQuery, Status: 429, Headers: map[string]string{"Retry-After": "0"}, Body: json.RawMessage(`{}`)}}, nil, 2},
+		{"fails fast on a long Retry-After", []tmdbtest.Fixture{{Path: "/movie/550", Query: movieQuery, Status: 429, Headers: map[string]string{"Retry-After": "120"}, Body: json.RawMessage(`{}`)}}, tmdb.ErrRateLimited, 1},
+		{"does not retry a 404", []tmdbtest.Fixture{reply(404, `{"status_code":34,"status_message":"The resource you requested could not be found."}`)}, tmdb.ErrNotFound, 1},
+		{"does not retry a 401", []tmdbtest.Fixture{reply(401, `{"status_code":7}`)}, tmdb.ErrUnauthorized, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, c := fake(t)
+			srv.Queue(tc.replies...)
+			m, err := c.GetMovie(context.Background(), 550)
+			switch want := tc.want; {
+			case want == nil && (err != nil || m.Title != "Fight Club"):
+				t.Fatalf("GetMovie = %v, %v; want the fixture", m, err)
+			case want != nil:
+				var se *tmdb.StatusError
+				if _, ok := want.(*tmdb.StatusError); ok && !errors.As(err, &se) || !ok && !errors.Is(err, want) {
+					t.Fatalf("err = %v, want %v", err, want)
+				}
+			}
+			if n := len(srv.Requests()); n != tc.requests {
+				t.Fatalf("%d requests, want %d", n, tc.requests)
+			}
+		})
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	srv, c := fake(t)
+	c.Retry.Attempts = 1
+	c.SetBreaker(tmdb.BreakerPolicy{Failures: 2, Cooldown: time.Minute})
+	srv.Queue(reply(500, `{}`), reply(500, `{}`))
+	for range 2 {
+		if _, err := c.GetMovie(context.Background(), 550); err == nil {
+			t.Fatal("want the queued 500")
+		}
+	}
+	_, err := c.GetMovie(context.Background(), 550)
+	if !errors.Is(err, tmdb.ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+	if after, ok := tmdb.RetryAfter(err); !ok || after <= 0 || after > time.Minute {
+		t.Fatalf("RetryAfter = %v, %v", after, ok)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Fatalf("%d requests, want none while the breaker is open", n)
+	}
+}
+
+func TestBreakerCancelledProbe(t *testing.T) {
+	srv, c := fake(t)
+	c.Retry.Attempts = 1
+	c.SetBreaker(tmdb.BreakerPolicy{Failures: 2, Cooldown: 20 * time.Millisecond})
+	srv.Queue(reply(500, `{}`), reply(500, `{}`))
+	for range 2 {
+		_, _ = c.GetMovie(context.Background(), 550)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.GetMovie(ctx, 550); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled probe = %v, want context.Canceled", err)
+	}
+	// The breaker is still half-open, so one more failed probe reopens it.
+	srv.Queue(reply(500, `{}`))
+	_, _ = c.GetMovie(context.Background(), 550)
+	if _, err := c.GetMovie(context.Background(), 550); !errors.Is(err, tmdb.ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable after the next probe failed", err)
+	}
+}
+
+func TestBreakerIgnores4xx(t *testing.T) {
+	srv, c := fake(t)
+	c.Retry.Attempts = 1
+	c.SetBreaker(tmdb.BreakerPolicy{Failures: 3, Cooldown: time.Minute})
+	notFound := reply(404, `{"status_code":34,"status_message":"The resource you requested could not be found."}`)
+	srv.Queue(reply(500, `{}`), notFound, reply(503, `{}`), notFound, reply(502, `{}`))
+	for range 5 {
+		_, _ = c.GetMovie(context.Background(), 550)
+	}
+	if _, err := c.GetMovie(context.Background(), 550); !errors.Is(err, tmdb.ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable after three 5xx between 404s", err)
+	}
+	if n := len(srv.Requests()); n != 5 {
+		t.Fatalf("%d requests, want none while the breaker is open", n)
+	}
+}
+
+func TestLocalizedRequests(t *testing.T) {
+	srv, c := fake(t)
+	ctx := i18n.NewContext(context.Background(), i18n.Parse("fr-FR,fr;q=0.9"))
+	_, _ = c.GetMovie(ctx, 550)
+	_, _ = c.TrendingMovies(ctx, "week", 1, "US")
+	_, _ = c.GetMovie(context.Background(), 550)
+	want := []string{
+		"/movie/550?append_to_response=credits%2Cvideos%2Cexternal_ids&language=fr-FR&region=FR",
+		"/trending/movie/week?language=fr-FR&region=US",
+		"/movie/550?append_to_response=credits%2Cvideos%2Cexternal_ids",
+	}
+	if got := srv.Requests(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("requests:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
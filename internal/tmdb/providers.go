@@ -0,0 +1,57 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// WatchProviders lists where a movie or show can be watched, keyed by ISO
+// 3166-1 country code. TMDb gets this data from JustWatch.
+type WatchProviders struct {
+	ID      int64                       `json:"id"`
+	Results map[string]CountryProviders `json:"results"`
+}
+
+// CountryProviders are the services offering a title in one country, by
+// how they offer it. Link is TMDb's page listing them.
+type CountryProviders struct {
+	Link     string     `json:"link,omitempty"`
+	Flatrate []Provider `json:"flatrate,omitempty"` // subscription streaming
+	Free     []Provider `json:"free,omitempty"`
+	Ads      []Provider `json:"ads,omitempty"`
+	Rent     []Provider `json:"rent,omitempty"`
+	Buy      []Provider `json:"buy,omitempty"`
+}
+
+type Provider struct {
+	ID       int64  `json:"provider_id"`
+	Name     string `json:"provider_name"`
+	LogoPath string `json:"logo_path"`
+	// DisplayPriority orders providers the way TMDb shows them, lowest first.
+	DisplayPriority int `json:"display_priority"`
+}
+
+// Region returns the providers for a country, or none when TMDb lists no
+// way to watch the title there.
+func (p *WatchProviders) Region(country string) CountryProviders {
+	return p.Results[country]
+}
+
+// MovieWatchProviders gets where a movie streams, rents and sells in every
+// country TMDb knows of.
+func (c *Client) MovieWatchProviders(ctx context.Context, id int64) (*WatchProviders, error) {
+	var out WatchProviders
+	if err := c.get(ctx, fmt.Sprintf("/movie/%d/watch/providers", id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// TVWatchProviders is MovieWatchProviders for a show.
+func (c *Client) TVWatchProviders(ctx context.Context, id int64) (*WatchProviders, error) {
+	var out WatchProviders
+	if err := c.get(ctx, fmt.Sprintf("/tv/%d/watch/providers", id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,240 @@
+package tmdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Errors callers can match with errors.Is. Failed replies are *StatusError
+// values that match the sentinel for their status.
+var (
+	ErrNotFound     = errors.New("tmdb: not found")
+	ErrUnauthorized = errors.New("tmdb: unauthorized")
+	ErrRateLimited  = errors.New("tmdb: rate limited")
+	// ErrUnavailable means the circuit breaker is open after repeated
+	// failures and calls fail fast until it cools down.
+	ErrUnavailable = errors.New("tmdb: unavailable")
+)
+
+// StatusError is a non-200 reply.
+type StatusError struct {
+	Status int
+	// Code and Message are TMDb's status_code and status_message, when sent.
+	Code    int
+	Message string
+	// RetryAfter is the wait the server asked for, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("tmdb status %d", e.Status)
+	}
+	return fmt.Sprintf("tmdb status %d: %s", e.Status, e.Message)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized
+	case ErrRateLimited:
+		return e.Status == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// openError is returned while the breaker is open.
+type openError struct{ retryAfter time.Duration }
+
+func (e *openError) Error() string {
+	return fmt.Sprintf("tmdb: unavailable, circuit open for %s", e.retryAfter.Round(time.Second))
+}
+
+func (e *openError) Is(target error) bool { return target == ErrUnavailable }
+
+// RetryAfter reports how long to wait before calling again after err, when
+// TMDb or the breaker said so.
+func RetryAfter(err error) (time.Duration, bool) {
+	var se *StatusError
+	if errors.As(err, &se) && se.RetryAfter > 0 {
+		return se.RetryAfter, true
+	}
+	var oe *openError
+	if errors.As(err, &oe) {
+		return oe.retryAfter, true
+	}
+	return 0, false
+}
+
+// RetryPolicy controls retries of failed GETs on network errors, 429 and
+// 5xx replies.
+type RetryPolicy struct {
+	// Attempts counts the first try; 1 disables retries.
+	Attempts int
+	// BaseDelay is the first backoff. It doubles on each retry and the
+	// actual wait is drawn uniformly below it (full jitter).
+	BaseDelay time.Duration
+	// MaxDelay caps one wait. A Retry-After longer than this is not waited
+	// out; the call fails with ErrRateLimited instead.
+	MaxDelay time.Duration
+}
+
+// backoff returns the wait before retry number n (1-based).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.BaseDelay << (n - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d) + 1
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// limiter is a token bucket shared by every call on a Client; wait blocks
+// until a token is free. A zero rate never blocks.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(perSecond float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{rate: perSecond, burst: float64(burst), tokens: float64(burst)}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	// Take the token now, possibly going negative: that reserves a slot
+	// in the queue so waiters are served in order.
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	return sleep(ctx, wait)
+}
+
+// BreakerPolicy opens the circuit after Failures failed calls (network
+// errors and 5xx after retries) with no success in between, and fails fast
+// for Cooldown. The first call after that is let through as a probe.
+type BreakerPolicy struct {
+	Failures int
+	Cooldown time.Duration
+}
+
+type breaker struct {
+	policy    BreakerPolicy
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *breaker) allow() error {
+	if b == nil || b.policy.Failures <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return nil
+	}
+	if left := time.Until(b.openUntil); left > 0 {
+		return &openError{retryAfter: left}
+	}
+	if b.probing {
+		return &openError{retryAfter: b.policy.Cooldown}
+	}
+	b.probing = true
+	return nil
+}
+
+// outcome is what a call tells the breaker about TMDb's health.
+type outcome int
+
+const (
+	// succeeded closes the breaker and clears the failure count.
+	succeeded outcome = iota
+	// failed counts towards opening it.
+	failed
+	// neutral says nothing either way, like a 404 or a caller giving up;
+	// it only ends a probe, so the next call probes again.
+	neutral
+)
+
+func (b *breaker) record(o outcome) {
+	if b == nil || b.policy.Failures <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	switch o {
+	case neutral:
+		return
+	case succeeded:
+		b.failures, b.openUntil = 0, time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= b.policy.Failures || !b.openUntil.IsZero() {
+		b.openUntil = time.Now().Add(b.policy.Cooldown)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,300 @@
+// Package tmdbtest is a fake TMDb API that serves recorded JSON fixtures,
+// so handlers, seed tooling and the API itself can run offline. Point a
+// client at it with tmdb.New("any-key", srv.URL).
+//
+// The bundled fixtures cover the movies in the seed fixture: their detail
+// pages, a few searches, day and week trending, popularity-sorted
+// discover and /configuration, plus a couple of shows with a season, tv
+// and multi searches, trending shows and watch providers for two movies
+// and a show. They are trimmed to the fields the client reads. In record
+// mode, requests without a fixture are forwarded to the real API and
+// successful replies saved to Options.Dir with the API key removed.
+package tmdbtest
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUpstream is where record mode fetches missing fixtures.
+const DefaultUpstream = "https://api.themoviedb.org/3"
+
+//go:embed fixtures/*.json
+var bundled embed.FS
+
+// Fixture is one recorded exchange.
+type Fixture struct {
+	// Path is the request path without the /3 version prefix, e.g. /movie/550.
+	Path string `json:"path"`
+	// Query is the canonical query (see Key) without api_key.
+	Query  string `json:"query"`
+	Status int    `json:"status"`
+	// Headers are extra reply headers, e.g. Retry-After.
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// Key identifies the fixture for a request: the path plus the sorted query
+// without api_key and without page=1, which TMDb treats as the default.
+func Key(path string, q url.Values) string {
+	c := url.Values{}
+	for k, v := range q {
+		if k == "api_key" || (k == "page" && len(v) == 1 && v[0] == "1") {
+			continue
+		}
+		c[k] = v
+	}
+	return path + "?" + c.Encode()
+}
+
+// Options configures a Handler. The zero value serves the bundled
+// fixtures only.
+type Options struct {
+	// Dir holds fixtures on disk. They take precedence over the bundled
+	// ones, and record mode writes new ones here.
+	Dir string
+	// Record forwards requests that have no fixture to Upstream with
+	// APIKey and saves the successful replies. Errors are passed on but
+	// not saved, so a bad key or a rate limit is not replayed later.
+	Record   bool
+	Upstream string
+	APIKey   string
+	// RecordNotFound also saves 404 replies, for titles meant to be missing.
+	RecordNotFound bool
+}
+
+// recordedHeaders are the upstream reply headers kept with a fixture.
+var recordedHeaders = []string{"Retry-After"}
+
+// Handler serves fixtures over HTTP.
+type Handler struct {
+	opts     Options
+	client   *http.Client
+	mu       sync.Mutex
+	fixtures map[string]Fixture
+	queued   map[string][]Fixture
+	requests []string
+}
+
+// NewHandler loads the bundled fixtures and those in opts.Dir.
+func NewHandler(opts Options) (*Handler, error) {
+	if opts.Record && (opts.Dir == "" || opts.APIKey == "") {
+		return nil, errors.New("tmdbtest: record mode needs a fixture dir and an API key")
+	}
+	if opts.Upstream == "" {
+		opts.Upstream = DefaultUpstream
+	}
+	h := &Handler{opts: opts, client: &http.Client{Timeout: 30 * time.Second}, fixtures: map[string]Fixture{}, queued: map[string][]Fixture{}}
+	if err := h.load(bundled, "fixtures"); err != nil {
+		return nil, err
+	}
+	if opts.Dir != "" {
+		if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+			return nil, err
+		}
+		if err := h.load(os.DirFS(opts.Dir), ""); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+func (h *Handler) load(fsys fs.FS, dir string) error {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		var f Fixture
+		if err := json.Unmarshal(b, &f); err != nil {
+			return fmt.Errorf("tmdbtest: %s: %w", name, err)
+		}
+		q, err := url.ParseQuery(f.Query)
+		if err != nil {
+			return fmt.Errorf("tmdbtest: %s: %w", name, err)
+		}
+		h.fixtures[Key(f.Path, q)] = f
+	}
+	return nil
+}
+
+// Set adds or replaces a fixture, e.g. to script an error reply.
+func (h *Handler) Set(f Fixture) {
+	q, _ := url.ParseQuery(f.Query)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fixtures[Key(f.Path, q)] = f
+}
+
+// Queue adds one-shot replies, served in order before the stored fixture
+// for the same request, e.g. a 503 followed by a success.
+func (h *Handler) Queue(fs ...Fixture) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, f := range fs {
+		q, _ := url.ParseQuery(f.Query)
+		key := Key(f.Path, q)
+		h.queued[key] = append(h.queued[key], f)
+	}
+}
+
+// Requests returns the keys of the requests served so far, in order.
+func (h *Handler) Requests() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.requests...)
+}
+
+// ServeHTTP answers like TMDb: 401 without an api_key, the fixture when
+// there is one and 404 otherwise.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, "/3")
+	q := r.URL.Query()
+	key := Key(p, q)
+	h.mu.Lock()
+	h.requests = append(h.requests, key)
+	f, ok := h.fixtures[key]
+	if q := h.queued[key]; len(q) > 0 && r.URL.Query().Get("api_key") != "" {
+		f, ok, h.queued[key] = q[0], true, q[1:]
+	}
+	h.mu.Unlock()
+
+	switch {
+	case r.Method != http.MethodGet:
+		writeError(w, http.StatusMethodNotAllowed, 0, "The fake only serves GET requests.")
+		return
+	case q.Get("api_key") == "":
+		writeError(w, http.StatusUnauthorized, 7, "Invalid API key: You must be granted a valid key.")
+		return
+	case !ok && h.opts.Record:
+		var err error
+		if f, err = h.record(p, q); err != nil {
+			writeError(w, http.StatusBadGateway, 0, err.Error())
+			return
+		}
+	case !ok:
+		writeError(w, http.StatusNotFound, 34, "The resource you requested could not be found.")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	for k, v := range f.Headers {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(f.Status)
+	_, _ = w.Write(f.Body)
+}
+
+func writeError(w http.ResponseWriter, status, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"success": false, "status_code": code, "status_message": msg})
+}
+
+// record fetches path from upstream with the real key and, for a 2xx (or
+// an allowed 404), saves the reply under its key with every trace of the
+// key removed. Other replies are returned without being saved.
+func (h *Handler) record(path string, q url.Values) (Fixture, error) {
+	up := url.Values{}
+	for k, v := range q {
+		up[k] = v
+	}
+	up.Set("api_key", h.opts.APIKey)
+	res, err := h.client.Get(h.opts.Upstream + path + "?" + up.Encode())
+	if err != nil {
+		// The URL carries the API key.
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			err = ue.Err
+		}
+		return Fixture{}, fmt.Errorf("record %s: %w", path, err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("record %s: %w", path, err)
+	}
+	if !json.Valid(body) {
+		return Fixture{}, fmt.Errorf("record %s: upstream replied %d with non-JSON body", path, res.StatusCode)
+	}
+	body = bytes.ReplaceAll(body, []byte(h.opts.APIKey), []byte("REDACTED"))
+	key := Key(path, q)
+	f := Fixture{Path: path, Query: strings.TrimPrefix(key, path+"?"), Status: res.StatusCode, Body: body}
+	for _, name := range recordedHeaders {
+		if v := res.Header.Get(name); v != "" {
+			if f.Headers == nil {
+				f.Headers = map[string]string{}
+			}
+			f.Headers[name] = strings.ReplaceAll(v, h.opts.APIKey, "REDACTED")
+		}
+	}
+	ok := res.StatusCode/100 == 2 || res.StatusCode == http.StatusNotFound && h.opts.RecordNotFound
+	if !ok {
+		return f, nil
+	}
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return Fixture{}, err
+	}
+	if err := os.WriteFile(filepath.Join(h.opts.Dir, FileName(key)), append(b, '\n'), 0o644); err != nil {
+		return Fixture{}, err
+	}
+	h.mu.Lock()
+	h.fixtures[key] = f
+	h.mu.Unlock()
+	return f, nil
+}
+
+// FileName is the file a fixture with key is stored in.
+func FileName(key string) string {
+	var b strings.Builder
+	underscore := true
+	for _, r := range strings.ToLower(key) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_") + ".json"
+}
+
+// Server runs a Handler on a local port.
+type Server struct {
+	*Handler
+	// URL is the base URL to hand to tmdb.New; it ends in /3 like the real one.
+	URL string
+	srv *httptest.Server
+}
+
+// NewServer starts a fake TMDb; Close it when done.
+func NewServer(opts Options) (*Server, error) {
+	h, err := NewHandler(opts)
+	if err != nil {
+		return nil, err
+	}
+	srv := httptest.NewServer(h)
+	return &Server{Handler: h, URL: srv.URL + "/3", srv: srv}, nil
+}
+
+// Close stops the server.
+func (s *Server) Close() { s.srv.Close() }
@@ -0,0 +1,127 @@
+package tmdbtest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourname/moodle/internal/tmdb/tmdbtest"
+)
+
+const realKey = "real-key-0123456789"
+
+// upstream echoes the key back, as some TMDb replies quote the request URL.
+func upstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != realKey {
+			t.Errorf("upstream got api_key %q, want the real key", r.URL.Query().Get("api_key"))
+		}
+		switch r.URL.Path {
+		case "/movie/603":
+			_, _ = w.Write([]byte(`{"id":603,"title":"The Matrix","note":"fetched with ` + r.URL.RawQuery + `"}`))
+		case "/movie/1":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status_code":34}`))
+		case "/movie/2":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"status_code":25}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status_code":7}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func recorder(t *testing.T, notFound bool) (string, *httptest.Server) {
+	t.Helper()
+	dir := t.TempDir()
+	h, err := tmdbtest.NewHandler(tmdbtest.Options{Dir: dir, Record: true, RecordNotFound: notFound, Upstream: upstream(t).URL, APIKey: realKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return dir, srv
+}
+
+func get(t *testing.T, srv *httptest.Server, path string) *http.Response {
+	t.Helper()
+	res, err := http.Get(srv.URL + "/3" + path + "?api_key=client-key&language=de-DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res
+}
+
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestRecordStripsKey(t *testing.T) {
+	dir, srv := recorder(t, false)
+	if res := get(t, srv, "/movie/603"); res.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", res.StatusCode)
+	}
+	names := files(t, dir)
+	if len(names) != 1 {
+		t.Fatalf("recorded %v, want one fixture", names)
+	}
+	if strings.Contains(names[0], "real") || strings.Contains(names[0], "client") {
+		t.Fatalf("file name %q carries a key", names[0])
+	}
+	b, err := os.ReadFile(filepath.Join(dir, names[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), realKey) || strings.Contains(string(b), "client-key") || !strings.Contains(string(b), "The Matrix") {
+		t.Fatalf("fixture leaks a key or lost the body:\n%s", b)
+	}
+
+	// The saved fixture is served from then on, without asking upstream.
+	if res := get(t, srv, "/movie/603"); res.StatusCode != http.StatusOK {
+		t.Fatalf("replay status %d", res.StatusCode)
+	}
+}
+
+func TestRecordSkipsErrors(t *testing.T) {
+	dir, srv := recorder(t, false)
+	res := get(t, srv, "/movie/2")
+	if res.StatusCode != http.StatusTooManyRequests || res.Header.Get("Retry-After") != "7" {
+		t.Fatalf("rate limited reply = %d, Retry-After %q", res.StatusCode, res.Header.Get("Retry-After"))
+	}
+	if res := get(t, srv, "/movie/1"); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing movie = %d", res.StatusCode)
+	}
+	if res := get(t, srv, "/configuration/unknown"); res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("bad key reply = %d", res.StatusCode)
+	}
+	if names := files(t, dir); len(names) != 0 {
+		t.Fatalf("error replies were saved: %v", names)
+	}
+}
+
+func TestRecordNotFound(t *testing.T) {
+	dir, srv := recorder(t, true)
+	get(t, srv, "/movie/1")
+	get(t, srv, "/movie/2")
+	if names := files(t, dir); len(names) != 1 || !strings.HasPrefix(names[0], "movie_1_") {
+		t.Fatalf("recorded %v, want only the 404", names)
+	}
+}
@@ -0,0 +1,169 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// TVShow is a series as listed in search and trending results. GetTV fills
+// in the detail fields too.
+type TVShow struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	Overview     string  `json:"overview"`
+	PosterPath   string  `json:"poster_path"`
+	BackdropPath string  `json:"backdrop_path"`
+	FirstAirDate string  `json:"first_air_date"`
+	VoteAverage  float64 `json:"vote_average,omitempty"`
+	VoteCount    int     `json:"vote_count,omitempty"`
+
+	LastAirDate      string    `json:"last_air_date,omitempty"`
+	Status           string    `json:"status,omitempty"`
+	Tagline          string    `json:"tagline,omitempty"`
+	Genres           []Genre   `json:"genres,omitempty"`
+	EpisodeRunTime   []int     `json:"episode_run_time,omitempty"` // minutes
+	NumberOfSeasons  int       `json:"number_of_seasons,omitempty"`
+	NumberOfEpisodes int       `json:"number_of_episodes,omitempty"`
+	CreatedBy        []Creator `json:"created_by,omitempty"`
+	Networks         []Network `json:"networks,omitempty"`
+	Seasons          []Season  `json:"seasons,omitempty"`
+}
+
+type Creator struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	ProfilePath string `json:"profile_path"`
+}
+
+type Network struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	LogoPath string `json:"logo_path"`
+}
+
+// Season is a season as listed on a show, or with its Episodes from
+// GetSeason.
+type Season struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Overview     string    `json:"overview"`
+	PosterPath   string    `json:"poster_path"`
+	SeasonNumber int       `json:"season_number"`
+	AirDate      string    `json:"air_date"`
+	EpisodeCount int       `json:"episode_count,omitempty"`
+	Episodes     []Episode `json:"episodes,omitempty"`
+}
+
+type Episode struct {
+	ID            int64   `json:"id"`
+	Name          string  `json:"name"`
+	Overview      string  `json:"overview"`
+	SeasonNumber  int     `json:"season_number"`
+	EpisodeNumber int     `json:"episode_number"`
+	AirDate       string  `json:"air_date"`
+	Runtime       int     `json:"runtime,omitempty"`
+	StillPath     string  `json:"still_path"`
+	VoteAverage   float64 `json:"vote_average,omitempty"`
+}
+
+type SearchTVResponse struct {
+	Page         int      `json:"page"`
+	TotalPages   int      `json:"total_pages"`
+	TotalResults int      `json:"total_results"`
+	Results      []TVShow `json:"results"`
+}
+
+type TrendingTVResponse struct {
+	Page    int      `json:"page"`
+	Results []TVShow `json:"results"`
+}
+
+// Media types in multi search results.
+const (
+	MediaMovie  = "movie"
+	MediaTV     = "tv"
+	MediaPerson = "person"
+)
+
+// MultiResult is one movie, show or person from SearchMulti. Movies set
+// Title and ReleaseDate, shows and people set Name, shows set FirstAirDate
+// and people ProfilePath.
+type MultiResult struct {
+	MediaType    string  `json:"media_type"`
+	ID           int64   `json:"id"`
+	Title        string  `json:"title,omitempty"`
+	Name         string  `json:"name,omitempty"`
+	Overview     string  `json:"overview,omitempty"`
+	PosterPath   string  `json:"poster_path,omitempty"`
+	ProfilePath  string  `json:"profile_path,omitempty"`
+	ReleaseDate  string  `json:"release_date,omitempty"`
+	FirstAirDate string  `json:"first_air_date,omitempty"`
+	VoteAverage  float64 `json:"vote_average,omitempty"`
+}
+
+type SearchMultiResponse struct {
+	Page         int           `json:"page"`
+	TotalPages   int           `json:"total_pages"`
+	TotalResults int           `json:"total_results"`
+	Results      []MultiResult `json:"results"`
+}
+
+func (c *Client) SearchTV(ctx context.Context, query string, page int) (*SearchTVResponse, error) {
+	var out SearchTVResponse
+	if err := c.get(ctx, "/search/tv", searchQuery(query, page), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchMulti searches movies, shows and people at once.
+func (c *Client) SearchMulti(ctx context.Context, query string, page int) (*SearchMultiResponse, error) {
+	var out SearchMultiResponse
+	if err := c.get(ctx, "/search/multi", searchQuery(query, page), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTV gets a show's details, including its season list.
+func (c *Client) GetTV(ctx context.Context, id int64) (*TVShow, error) {
+	var out TVShow
+	if err := c.get(ctx, fmt.Sprintf("/tv/%d", id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSeason gets one season of a show with its episodes.
+func (c *Client) GetSeason(ctx context.Context, id int64, season int) (*Season, error) {
+	var out Season
+	if err := c.get(ctx, fmt.Sprintf("/tv/%d/season/%d", id, season), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// TrendingTV gets trending shows for a given window (day|week) and page.
+func (c *Client) TrendingTV(ctx context.Context, window string, page int) (*TrendingTVResponse, error) {
+	if window == "" {
+		window = "day"
+	}
+	q := url.Values{}
+	if page > 0 {
+		q.Set("page", fmt.Sprint(page))
+	}
+	var out TrendingTVResponse
+	if err := c.get(ctx, "/trending/tv/"+window, q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func searchQuery(query string, page int) url.Values {
+	q := url.Values{"query": {query}}
+	if page > 0 {
+		q.Set("page", fmt.Sprint(page))
+	}
+	return q
+}
@@ -0,0 +1,123 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+
+	"github.com/yourname/moodle/internal/models"
+	"github.com/yourname/moodle/internal/store/pgtest"
+	"github.com/yourname/moodle/internal/tracing"
+	"github.com/yourname/moodle/internal/tracing/tracingtest"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+// collect exports spans to a tracingtest collector for the rest of t. Call
+// the returned function to flush them before reading col.Spans.
+func collect(t *testing.T) (col *tracingtest.Collector, flush func()) {
+	t.Helper()
+	col = tracingtest.NewCollector()
+	t.Cleanup(col.Close)
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{Enabled: true, ServiceName: "tracing-test", Endpoint: col.Endpoint(), Insecure: true, SampleRatio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return col, func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func span(t *testing.T, col *tracingtest.Collector, name string) *tracepb.Span {
+	t.Helper()
+	for _, s := range col.Spans() {
+		if s.GetName() == name {
+			return s
+		}
+	}
+	t.Fatalf("no %q span in %v", name, col.SpanNames())
+	return nil
+}
+
+func TestRequestAndClientSpans(t *testing.T) {
+	col, flush := collect(t)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Traceparent") == "" {
+			t.Error("outbound call carries no traceparent")
+		}
+	}))
+	defer upstream.Close()
+	client := &http.Client{}
+	tracing.InstrumentClient(client, "tmdb")
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID, tracing.Middleware)
+	r.Get("/v1/movies/{id}", func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL+"/3/movie/550?api_key=secret-key", nil)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		res.Body.Close()
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/movies/550", nil))
+	flush()
+
+	server := span(t, col, "GET /v1/movies/{id}")
+	if got := w.Header().Get("X-Trace-Id"); got != hex.EncodeToString(server.GetTraceId()) {
+		t.Errorf("X-Trace-Id = %q, want the server span's trace %x", got, server.GetTraceId())
+	}
+	if tracingtest.Attr(server, "http.request_id") == "" {
+		t.Error("server span has no request ID")
+	}
+	out := span(t, col, "tmdb GET")
+	if string(out.GetParentSpanId()) != string(server.GetSpanId()) {
+		t.Error("client span is not a child of the server span")
+	}
+	for _, kv := range out.GetAttributes() {
+		if strings.Contains(kv.GetValue().GetStringValue(), "secret-key") {
+			t.Errorf("client span attribute %s leaks the API key: %s", kv.GetKey(), kv.GetValue().GetStringValue())
+		}
+	}
+}
+
+func TestGormSpans(t *testing.T) {
+	db := pgtest.DB(t)
+	col, flush := collect(t)
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	var users []models.User
+	if err := db.WithContext(ctx).Where("email = ?", "secret@example.test").Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+	flush()
+
+	q := span(t, col, "gorm.query")
+	if string(q.GetParentSpanId()) != string(span(t, col, "parent").GetSpanId()) {
+		t.Error("query span is not a child of the caller's span")
+	}
+	if text := tracingtest.Attr(q, "db.query.text"); !strings.Contains(text, "users") || strings.Contains(text, "secret@example.test") {
+		t.Errorf("db.query.text = %q, want the SQL without bind values", text)
+	}
+	if got := tracingtest.Attr(q, "db.system"); got != "postgresql" {
+		t.Errorf("db.system = %q, want postgresql", got)
+	}
+}
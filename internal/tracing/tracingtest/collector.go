@@ -0,0 +1,124 @@
+// Package tracingtest provides an in-process OTLP/HTTP collector so
+// tracing can be exercised without running a real collector.
+package tracingtest
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Collector accepts OTLP/HTTP protobuf exports on /v1/traces and keeps
+// every span it receives.
+type Collector struct {
+	srv   *httptest.Server
+	mu    sync.Mutex
+	spans []*tracepb.Span
+}
+
+// NewCollector starts a collector; Close it when done.
+func NewCollector() *Collector {
+	c := &Collector{}
+	c.srv = httptest.NewServer(http.HandlerFunc(c.serve))
+	return c
+}
+
+// Endpoint is the host:port to put in tracing.Config.Endpoint (with Insecure).
+func (c *Collector) Endpoint() string {
+	return strings.TrimPrefix(c.srv.URL, "http://")
+}
+
+// Close stops the collector.
+func (c *Collector) Close() { c.srv.Close() }
+
+// Spans returns a copy of the spans received so far.
+func (c *Collector) Spans() []*tracepb.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*tracepb.Span(nil), c.spans...)
+}
+
+// SpanNames returns the names of the spans received so far, in arrival order.
+func (c *Collector) SpanNames() []string {
+	spans := c.Spans()
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.GetName()
+	}
+	return names
+}
+
+// Reset drops the spans received so far.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	c.spans = nil
+	c.mu.Unlock()
+}
+
+func (c *Collector) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" {
+		http.NotFound(w, r)
+		return
+	}
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(raw, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			c.spans = append(c.spans, ss.GetSpans()...)
+		}
+	}
+	c.mu.Unlock()
+
+	out, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(out)
+}
+
+// Attr returns the value of span attribute key as a string, or "" when the
+// span does not have it.
+func Attr(s *tracepb.Span, key string) string {
+	for _, kv := range s.GetAttributes() {
+		if kv.GetKey() != key {
+			continue
+		}
+		switch v := kv.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			return v.StringValue
+		case *commonpb.AnyValue_IntValue:
+			return strconv.FormatInt(v.IntValue, 10)
+		case *commonpb.AnyValue_BoolValue:
+			return strconv.FormatBool(v.BoolValue)
+		case *commonpb.AnyValue_DoubleValue:
+			return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64)
+		}
+	}
+	return ""
+}
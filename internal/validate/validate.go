@@ -1,20 +1,45 @@
 package validate
 
 import (
-	"fmt"
+	"context"
+	"reflect"
+	"strings"
+	"unicode"
 
 	"github.com/go-playground/validator/v10"
+
+	"github.com/yourname/moodle/internal/i18n"
 )
 
-var v = validator.New(validator.WithRequiredStructEnabled())
+var v = newValidator()
+
+func newValidator() *validator.Validate {
+	val := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by their json (or query) name so errors match the API docs.
+	val.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return ""
+	})
+	return val
+}
 
-// Map returns field->message errors for struct validation tags.
-func Map(s any) map[string]string {
+// Map returns field->message errors for struct validation tags, with the
+// messages in the language of ctx.
+func Map(ctx context.Context, s any) map[string]string {
 	if err := v.Struct(s); err != nil {
 		if verrs, ok := err.(validator.ValidationErrors); ok {
+			loc := i18n.FromContext(ctx)
 			m := make(map[string]string, len(verrs))
 			for _, fe := range verrs {
-				m[fieldName(fe)] = messageFor(fe)
+				m[fieldName(fe)] = messageFor(loc, fe)
 			}
 			return m
 		}
@@ -36,26 +61,30 @@ func toLowerFirst(s string) string {
 		return s
 	}
 	r := []rune(s)
-	r[0] = []rune(string(r[0]))[0] + 32
+	r[0] = unicode.ToLower(r[0])
 	return string(r)
 }
 
-func messageFor(fe validator.FieldError) string {
+func messageFor(loc i18n.Locale, fe validator.FieldError) string {
 	switch fe.Tag() {
 	case "required":
-		return "is required"
+		return loc.Text("is required")
 	case "min":
-		return fmt.Sprintf("must be at least %s characters", fe.Param())
+		return loc.Sprintf("must be at least %v characters", fe.Param())
 	case "max":
-		return fmt.Sprintf("must be at most %s characters", fe.Param())
+		return loc.Sprintf("must be at most %v characters", fe.Param())
+	case "len":
+		return loc.Sprintf("must be exactly %v characters", fe.Param())
 	case "gte":
-		return fmt.Sprintf("must be >= %s", fe.Param())
+		return loc.Sprintf("must be >= %v", fe.Param())
 	case "lte":
-		return fmt.Sprintf("must be <= %s", fe.Param())
+		return loc.Sprintf("must be <= %v", fe.Param())
 	case "oneof":
-		return fmt.Sprintf("must be one of %s", fe.Param())
+		return loc.Sprintf("must be one of %v", fe.Param())
 	case "gt":
-		return fmt.Sprintf("must be > %s", fe.Param())
+		return loc.Sprintf("must be > %v", fe.Param())
+	case "uuid":
+		return loc.Text("must be a UUID")
 	default:
 		return fe.Error()
 	}
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE watchlists ADD COLUMN IF NOT EXISTS moderation_status text NOT NULL DEFAULT 'ok';
+ALTER TABLE watchlist_items ADD COLUMN IF NOT EXISTS moderation_status text NOT NULL DEFAULT 'ok';
+
+CREATE INDEX IF NOT EXISTS idx_watchlists_pending_review ON watchlists(created_at) WHERE moderation_status = 'pending_review';
+CREATE INDEX IF NOT EXISTS idx_items_pending_review ON watchlist_items(created_at) WHERE moderation_status = 'pending_review';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_items_pending_review;
+DROP INDEX IF EXISTS idx_watchlists_pending_review;
+ALTER TABLE watchlist_items DROP COLUMN IF EXISTS moderation_status;
+ALTER TABLE watchlists DROP COLUMN IF EXISTS moderation_status;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Token buckets for the shared rate limiter. Losing them on a crash only
+-- resets limits, so skip the WAL.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+    key text PRIMARY KEY,
+    tokens double precision NOT NULL,
+    allowed boolean NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS rate_limits;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Items of lists deleted before deletes became transactional were left live.
+UPDATE watchlist_items i SET deleted_at = w.deleted_at
+FROM watchlists w
+WHERE w.id = i.watchlist_id AND w.deleted_at IS NOT NULL AND i.deleted_at IS NULL;
+
+-- Concurrent adds could share a position; renumber live items in their
+-- current order before enforcing uniqueness.
+UPDATE watchlist_items i SET position = r.rn - 1
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY watchlist_id ORDER BY position, created_at, id) AS rn
+    FROM watchlist_items
+    WHERE deleted_at IS NULL
+) r
+WHERE r.id = i.id AND i.position <> r.rn - 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_items_watchlist_position ON watchlist_items(watchlist_id, position) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_items_watchlist_position;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- TMDb details per movie, read through by the API and refreshed in the
+-- background once fetched_at is older than MOVIE_CACHE_TTL.
+CREATE TABLE IF NOT EXISTS movies (
+    tmdb_id bigint PRIMARY KEY,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+
+    title text NOT NULL DEFAULT '',
+    poster_path text NOT NULL DEFAULT '',
+    release_date text NOT NULL DEFAULT '',
+    details jsonb NOT NULL,
+    fetched_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_movies_fetched_at ON movies(fetched_at);
+
+-- Seed from the copies on existing items, newest first, dated at the epoch
+-- so the refresher replaces them with real details on its first runs.
+INSERT INTO movies (tmdb_id, title, poster_path, release_date, details, fetched_at)
+SELECT DISTINCT ON (tmdb_id) tmdb_id, COALESCE(title, ''), COALESCE(poster_path, ''), COALESCE(release_date, ''),
+       jsonb_build_object('id', tmdb_id, 'title', COALESCE(title, ''), 'poster_path', COALESCE(poster_path, ''), 'release_date', COALESCE(release_date, '')),
+       to_timestamp(0)
+FROM watchlist_items
+WHERE deleted_at IS NULL AND tmdb_id > 0
+ORDER BY tmdb_id, updated_at DESC
+ON CONFLICT (tmdb_id) DO NOTHING;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_movies_fetched_at;
+DROP TABLE IF EXISTS movies;
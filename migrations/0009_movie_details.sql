@@ -0,0 +1,7 @@
+-- +goose Up
+-- Details cached before GetMovie appended credits, videos and external IDs
+-- lack them; date every row at the epoch so the refresher refetches them.
+UPDATE movies SET fetched_at = to_timestamp(0);
+
+-- +goose Down
+SELECT 1;
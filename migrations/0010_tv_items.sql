@@ -0,0 +1,55 @@
+-- +goose Up
+-- A title may appear on a list at most once, enforced below. Lists that
+-- already hold a movie twice are not cleaned up here, since which copy to
+-- keep (and its note) is the owner's call: the migration stops and lists
+-- how many there are. Find them with
+--   SELECT watchlist_id, tmdb_id, count(*) FROM watchlist_items
+--   WHERE deleted_at IS NULL GROUP BY 1, 2 HAVING count(*) > 1;
+-- remove the extra items, then migrate again.
+-- +goose StatementBegin
+DO $$
+DECLARE
+    dups bigint;
+BEGIN
+    SELECT count(*) INTO dups FROM (
+        SELECT 1 FROM watchlist_items
+        WHERE deleted_at IS NULL
+        GROUP BY watchlist_id, tmdb_id
+        HAVING count(*) > 1
+    ) d;
+    IF dups > 0 THEN
+        RAISE EXCEPTION '% movies are on a watchlist more than once; remove the extra items and migrate again', dups;
+    END IF;
+END $$;
+-- +goose StatementEnd
+
+ALTER TABLE watchlist_items ADD COLUMN IF NOT EXISTS media_type text NOT NULL DEFAULT 'movie';
+ALTER TABLE watchlist_items ADD CONSTRAINT watchlist_items_media_type_check CHECK (media_type IN ('movie', 'tv'));
+
+-- TMDb numbers movies and shows separately, so lookups by ID need the type.
+DROP INDEX IF EXISTS idx_items_tmdb;
+CREATE INDEX IF NOT EXISTS idx_items_media_tmdb ON watchlist_items(media_type, tmdb_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_items_watchlist_media_tmdb ON watchlist_items(watchlist_id, media_type, tmdb_id) WHERE deleted_at IS NULL;
+
+-- +goose Down
+-- Without media_type a show would read as the movie with the same ID, so
+-- refuse rather than drop or mislabel anyone's shows. Delete the tv rows
+-- yourself if losing them is intended.
+-- +goose StatementBegin
+DO $$
+DECLARE
+    shows bigint;
+BEGIN
+    SELECT count(*) INTO shows FROM watchlist_items WHERE media_type = 'tv';
+    IF shows > 0 THEN
+        RAISE EXCEPTION 'watchlist_items holds % TV show rows; delete them before rolling back', shows;
+    END IF;
+END $$;
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS idx_items_watchlist_media_tmdb;
+DROP INDEX IF EXISTS idx_items_media_tmdb;
+CREATE INDEX IF NOT EXISTS idx_items_tmdb ON watchlist_items(tmdb_id);
+ALTER TABLE watchlist_items DROP CONSTRAINT IF EXISTS watchlist_items_media_type_check;
+ALTER TABLE watchlist_items DROP COLUMN IF EXISTS media_type;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- How long the bucket's policy takes to refill from empty, so Prune only
+-- drops buckets that would be full again. Rows from before keep the old
+-- one-hour horizon until they are next touched.
+ALTER TABLE rate_limits ADD COLUMN IF NOT EXISTS refill interval NOT NULL DEFAULT interval '1 hour';
+
+-- +goose Down
+ALTER TABLE rate_limits DROP COLUMN IF EXISTS refill;